@@ -2,8 +2,10 @@ package commands
 
 import (
 	"fmt"
-	"strings"
+	"just-notify/notification"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -51,29 +53,92 @@ func GetTime(timeArg string) (int64, error) {
 
 	} else {
 
-		suffix := timeArg[len(timeArg)-1:]
+		duration, err := ParseDuration(timeArg)
 
-		if !strings.Contains("hms", suffix) {
-			return result, fmt.Errorf("Incorrect time suffix: \"%s\"", suffix)
+		if err != nil {
+			return result, err
 		}
 
-		numberStr := timeArg[:len(timeArg)-1]
-		number, err := strconv.Atoi(numberStr)
+		result = time.Now().Add(duration).UnixMilli()
+	}
 
-		if err != nil {
-			return result, err
+	return result, nil
+}
+
+// ParseWarnings converts a comma separated list of pre-alerts into warnings
+// between init and end. Each item is either a duration left before the
+// deadline (5m, 1h, 30s) or a percentage of the total time (50%).
+func ParseWarnings(spec string, init, end int64) ([]notification.Warning, error) {
+	var warnings []notification.Warning
+
+	if strings.TrimSpace(spec) == "" {
+		return warnings, nil
+	}
+
+	for item := range strings.SplitSeq(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var at int64
+		if percentStr, ok := strings.CutSuffix(item, "%"); ok {
+			percent, err := strconv.ParseFloat(percentStr, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Incorrect warning percentage: \"%s\"", item)
+			}
+			if percent <= 0 || percent >= 100 {
+				return nil, fmt.Errorf("Warning percentage out of range: \"%s\"", item)
+			}
+			at = init + int64(float64(end-init)*percent/100)
+		} else {
+			duration, err := ParseDuration(item)
+			if err != nil {
+				return nil, err
+			}
+			at = end - duration.Milliseconds()
 		}
 
-		switch suffix {
-		case "h":
-			result = time.Now().Add(time.Duration(number) * time.Hour).UnixMilli()
-		case "m":
-			result = time.Now().Add(time.Duration(number) * time.Minute).UnixMilli()
-		case "s":
-			result = time.Now().Add(time.Duration(number) * time.Second).UnixMilli()
+		if at <= init || at >= end {
+			return nil, fmt.Errorf("Warning \"%s\" is outside of the scheduled time", item)
 		}
+
+		warnings = append(warnings, notification.Warning{At: at, Label: item})
 	}
 
-	return result, nil
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].At < warnings[j].At
+	})
+
+	return warnings, nil
+}
+
+// ParseDuration parses a relative time like 30m, 1h or 45s
+func ParseDuration(timeArg string) (time.Duration, error) {
+	if len(timeArg) < 2 {
+		return 0, fmt.Errorf("Unexpected time argument: %s", timeArg)
+	}
+
+	suffix := timeArg[len(timeArg)-1:]
+
+	if !strings.Contains("hms", suffix) {
+		return 0, fmt.Errorf("Incorrect time suffix: \"%s\"", suffix)
+	}
+
+	numberStr := timeArg[:len(timeArg)-1]
+	number, err := strconv.Atoi(numberStr)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch suffix {
+	case "h":
+		return time.Duration(number) * time.Hour, nil
+	case "m":
+		return time.Duration(number) * time.Minute, nil
+	default:
+		return time.Duration(number) * time.Second, nil
+	}
 }
 
@@ -68,3 +68,40 @@ func TestGetTime(t *testing.T) {
 
 
 }
+
+func TestParseWarnings(t *testing.T) {
+	init := int64(1_000_000)
+	end := init + time.Hour.Milliseconds()
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []int64
+		wantErr bool
+	}{
+		{"Empty", "", nil, false},
+		{"Time left", "5m,1m", []int64{end - 5*time.Minute.Milliseconds(), end - time.Minute.Milliseconds()}, false},
+		{"Percentage", "50%", []int64{init + 30*time.Minute.Milliseconds()}, false},
+		{"Mixed and sorted", "1m,50%", []int64{init + 30*time.Minute.Milliseconds(), end - time.Minute.Milliseconds()}, false},
+		{"Longer than the task", "2h", nil, true},
+		{"Percentage out of range", "100%", nil, true},
+		{"Wrong format", "5x", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWarnings(tt.spec, init, end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWarnings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseWarnings() returned %d warnings, want %d", len(got), len(tt.want))
+			}
+			for i, w := range got {
+				if w.At != tt.want[i] {
+					t.Errorf("warning %d at %d, want %d", i, w.At, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const controlPathPattern = "/tmp/jn.%s.ctl"
+
+// SendControl queues a command for the running task of a category and wakes
+// the process up with SIGUSR1 so it reads it.
+func SendControl(category, command string) error {
+	category = strings.TrimSpace(category)
+	pidFile := fmt.Sprintf(pidPathPattern, category)
+
+	if _, err := os.Stat(pidFile); err != nil {
+		return fmt.Errorf("no running task for category %s: %w", category, err)
+	}
+
+	file, err := os.OpenFile(fmt.Sprintf(controlPathPattern, category), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("opening control file: %w", err)
+	}
+
+	_, err = fmt.Fprintln(file, command)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("writing control file: %w", err)
+	}
+
+	if err := exec.Command("pkill", "-SIGUSR1", "-F", pidFile).Run(); err != nil {
+		return fmt.Errorf("signaling process: %w", err)
+	}
+
+	return nil
+}
+
+// ReadControl returns the commands queued for a category and clears the queue.
+func ReadControl(category string) ([]string, error) {
+	controlFile := fmt.Sprintf(controlPathPattern, strings.TrimSpace(category))
+
+	// Move the queue away first, so commands sent meanwhile are not lost
+	pending := controlFile + ".reading"
+	if err := os.Rename(controlFile, pending); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading control file: %w", err)
+	}
+	defer os.Remove(pending)
+
+	content, err := os.ReadFile(pending)
+	if err != nil {
+		return nil, fmt.Errorf("reading control file: %w", err)
+	}
+
+	var cmds []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			cmds = append(cmds, line)
+		}
+	}
+
+	return cmds, nil
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeTask publishes the pid file of this process for a category and returns
+// the channel receiving its SIGUSR1
+func fakeTask(t *testing.T, category string) chan os.Signal {
+	t.Helper()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	t.Cleanup(func() { signal.Stop(signals) })
+
+	pidFile := fmt.Sprintf(pidPathPattern, category)
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0640); err != nil {
+		t.Fatalf("failed to write PID file: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Remove(pidFile)
+		os.Remove(fmt.Sprintf(controlPathPattern, category))
+	})
+
+	return signals
+}
+
+func waitSignal(t *testing.T, signals chan os.Signal) {
+	t.Helper()
+
+	select {
+	case <-signals:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no SIGUSR1 received")
+	}
+}
+
+func TestControlRoundTrip(t *testing.T) {
+	category := fmt.Sprintf("control-test-%d", os.Getpid())
+	signals := fakeTask(t, category)
+
+	if err := Ack([]string{"-c", category}, nil); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	waitSignal(t, signals)
+
+	if err := SendControl(category, "lap first note"); err != nil {
+		t.Fatalf("SendControl() error = %v", err)
+	}
+	waitSignal(t, signals)
+
+	cmds, err := ReadControl(category)
+	if err != nil {
+		t.Fatalf("ReadControl() error = %v", err)
+	}
+	if want := []string{"ack", "lap first note"}; !slices.Equal(cmds, want) {
+		t.Errorf("ReadControl() = %q, want %q", cmds, want)
+	}
+
+	// The queue is cleared once read
+	if cmds, err := ReadControl(category); err != nil || len(cmds) != 0 {
+		t.Errorf("second ReadControl() = %q, %v, want nothing", cmds, err)
+	}
+}
+
+func TestControlNoTask(t *testing.T) {
+	category := fmt.Sprintf("control-none-%d", os.Getpid())
+
+	if err := SendControl(category, "ack"); err == nil {
+		t.Error("SendControl() without a running task succeeded, want an error")
+	}
+	if err := Ack(nil, nil); err == nil {
+		t.Error("Ack() without a category succeeded, want an error")
+	}
+	if _, err := os.Stat(fmt.Sprintf(controlPathPattern, category)); !os.IsNotExist(err) {
+		t.Errorf("control file created without a running task, stat error = %v", err)
+	}
+}
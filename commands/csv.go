@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/database"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+type csvArgs struct {
+	CsvPath string `clap:"--csvpath,-C"`
+}
+
+// CSV maintains the CSV log: jn csv compact merges the rows appended for the
+// same task by older versions
+func CSV(args []string, cfg map[string]string) error {
+	if len(args) == 0 || args[0] != "compact" {
+		return fmt.Errorf("Usage: jn csv compact [-C <path>]")
+	}
+
+	cli := &csvArgs{}
+	if _, err := clap.Parse(args[1:], cli); err != nil {
+		return fmt.Errorf("parsing csv arguments: %w", err)
+	}
+
+	if cli.CsvPath == "" {
+		cli.CsvPath = cfg["CSV_PATH"]
+	}
+
+	logger, err := database.NewCSV(cli.CsvPath)
+	if err != nil {
+		return fmt.Errorf("opening CSV file: %w", err)
+	}
+	defer logger.Close()
+
+	removed, err := logger.Compact()
+	if err != nil {
+		return err
+	}
+
+	if removed == 0 {
+		fmt.Println("Nothing to compact")
+	} else {
+		fmt.Printf("Removed %d duplicate rows\n", removed)
+	}
+
+	return nil
+}
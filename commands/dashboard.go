@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/database"
+	"just-notify/ui"
+	"os"
+	"os/exec"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+const (
+	historyDays    = 7
+	historyLimit   = 50
+	historyRefresh = 5 * time.Second
+)
+
+type dashboardArgs struct {
+	UseDatabase bool   `clap:"--database,-d"`
+	ConnString  string `clap:"--conn,-s"`
+	CsvPath     string `clap:"--csvpath,-C"`
+}
+
+// Dashboard shows every running timer, today's totals and the recent history
+func Dashboard(args []string, cfg map[string]string) error {
+	cli := &dashboardArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing dashboard arguments: %w", err)
+	}
+
+	logger, err := openLogger(cfg, cli.UseDatabase, cli.ConnString, cli.CsvPath)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logger.Close()
+
+	theme, err := ui.LoadTheme(cfg)
+	if err != nil {
+		return fmt.Errorf("loading progress theme: %w", err)
+	}
+
+	return ui.Dashboard(&dashboardSource{logger: logger}, theme)
+}
+
+type dashboardSource struct {
+	logger   database.Logger
+	entries  []database.LogEntry
+	loadedAt time.Time
+}
+
+func (s *dashboardSource) Load() (ui.DashboardData, error) {
+	var data ui.DashboardData
+
+	tasks, err := RunningTasks()
+	if err != nil {
+		return data, err
+	}
+
+	for _, task := range tasks {
+		data.Timers = append(data.Timers, ui.DashboardTimer{
+			Category:    task.Category,
+			Description: task.Description,
+			State: ui.TimerState{
+				Elapsed: task.ElapsedNow(),
+				Planned: time.Duration(task.Planned) * time.Millisecond,
+				Paused:  task.Paused,
+			},
+		})
+	}
+
+	// The log changes only when a task starts or ends
+	if time.Since(s.loadedAt) > historyRefresh {
+		entries, err := s.logger.Query(database.Filter{
+			From:  time.Now().AddDate(0, 0, -historyDays).UnixMilli(),
+			Order: database.Descending,
+		})
+		if err != nil {
+			return data, err
+		}
+		s.entries = entries
+		s.loadedAt = time.Now()
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).UnixMilli()
+	totals := make(map[string]*ui.DashboardTotal)
+
+	for _, entry := range s.entries {
+		if len(data.History) < historyLimit {
+			item := ui.DashboardEntry{
+				Category:    entry.Category,
+				Description: entry.Description,
+				Start:       time.UnixMilli(entry.InitTime),
+			}
+			if entry.EndTime > 0 {
+				item.End = time.UnixMilli(entry.EndTime)
+			}
+			data.History = append(data.History, item)
+		}
+
+		if entry.InitTime >= startOfDay && entry.EndTime > entry.InitTime {
+			total, ok := totals[entry.Category]
+			if !ok {
+				total = &ui.DashboardTotal{Category: entry.Category}
+				totals[entry.Category] = total
+			}
+			total.Total += time.Duration(entry.EndTime-entry.InitTime) * time.Millisecond
+			total.Sessions++
+		}
+	}
+
+	for _, total := range totals {
+		data.Totals = append(data.Totals, *total)
+	}
+	sort.Slice(data.Totals, func(i, j int) bool {
+		if data.Totals[i].Total != data.Totals[j].Total {
+			return data.Totals[i].Total > data.Totals[j].Total
+		}
+		return data.Totals[i].Category < data.Totals[j].Category
+	})
+
+	return data, nil
+}
+
+// Start runs a new jn process in the background
+func (s *dashboardSource) Start(category, duration string) error {
+	if _, err := GetTime(duration); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding jn executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, "-t", duration, "-c", category)
+	// Detach it from the dashboard terminal
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting task: %w", err)
+	}
+
+	s.loadedAt = time.Time{}
+	return cmd.Process.Release()
+}
+
+func (s *dashboardSource) Stop(category string) error {
+	_, err := KillProcess(category)
+	s.loadedAt = time.Time{}
+	return err
+}
+
+func (s *dashboardSource) Pause(category string) error {
+	return SendControl(category, "pause")
+}
+
+func (s *dashboardSource) Resume(category string) error {
+	return SendControl(category, "resume")
+}
+
+func (s *dashboardSource) Extend(category string, d time.Duration) error {
+	return SendControl(category, "extend "+d.String())
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/database"
+	"time"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+type dbArgs struct {
+	ConnString string `clap:"--conn,-s"`
+}
+
+// DB manages the schema of the database: jn db status shows the version and
+// the pending migrations, jn db migrate applies them
+func DB(args []string, cfg map[string]string) error {
+	if len(args) == 0 || (args[0] != "status" && args[0] != "migrate") {
+		return fmt.Errorf("Usage: jn db status|migrate [-s <conn>]")
+	}
+	action := args[0]
+
+	cli := &dbArgs{}
+	if _, err := clap.Parse(args[1:], cli); err != nil {
+		return fmt.Errorf("parsing db arguments: %w", err)
+	}
+
+	if cli.ConnString == "" {
+		cli.ConnString = cfg["CONN"]
+	}
+
+	if cli.ConnString == "" {
+		return fmt.Errorf("No database configured, set CONN in ~/.jnconfig or use --conn")
+	}
+
+	schema, err := database.OpenSchema(cli.ConnString)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer schema.Close()
+
+	if action == "migrate" {
+		applied, err := schema.Migrate()
+		for _, m := range applied {
+			fmt.Printf("Applied %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Nothing to migrate")
+		}
+	}
+
+	return printSchemaStatus(schema)
+}
+
+func printSchemaStatus(schema *database.Schema) error {
+	version, err := schema.Version()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := schema.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Schema version %d of %d\n", version, schema.Latest())
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != 0 {
+			applied = time.UnixMilli(status.AppliedAt).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  %3d  %-32s %s\n", status.Version, status.Description, applied)
+	}
+
+	return nil
+}
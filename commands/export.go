@@ -0,0 +1,306 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"just-notify/config"
+	"just-notify/database"
+	"just-notify/ui"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+// Export formats
+const (
+	formatJSON      = "json"
+	formatNDJSON    = "ndjson"
+	formatICS       = "ics"
+	formatTimesheet = "timesheet-csv"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+type exportArgs struct {
+	UseDatabase bool   `clap:"--database,-d"`
+	ConnString  string `clap:"--conn,-s"`
+	CsvPath     string `clap:"--csvpath,-C"`
+	Format      string `clap:"--format,-F"`
+	From        string `clap:"--from,-f"`
+	To          string `clap:"--to,-t"`
+	Category    string `clap:"--cat,-c"`
+}
+
+// exportEntry is a finished session in the JSON formats
+type exportEntry struct {
+	ID          string      `json:"id,omitempty"`
+	Category    string      `json:"category"`
+	Description string      `json:"description,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+	Start       string      `json:"start"`
+	End         string      `json:"end"`
+	Duration    string      `json:"duration"`
+	DurationMs  int64       `json:"duration_ms"`
+	PlannedEnd  string      `json:"planned_end,omitempty"`
+	Planned     string      `json:"planned,omitempty"`
+	Unlimited   bool        `json:"unlimited,omitempty"`
+	Outcome     string      `json:"outcome,omitempty"`
+	Warnings    []string    `json:"warnings,omitempty"`
+	Overtime    string      `json:"overtime,omitempty"`
+	Laps        []exportLap `json:"laps,omitempty"`
+	Hostname    string      `json:"hostname,omitempty"`
+	User        string      `json:"user,omitempty"`
+}
+
+type exportLap struct {
+	Time    string `json:"time"`
+	Elapsed string `json:"elapsed"`
+	Note    string `json:"note,omitempty"`
+}
+
+// Export writes the finished sessions of a date range to stdout, for
+// timesheets and calendars
+func Export(args []string, cfg map[string]string) error {
+	cli := &exportArgs{}
+	args, tags, err := config.ExtractTags(args, cli)
+	if err != nil {
+		return err
+	}
+
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing export arguments: %w", err)
+	}
+
+	if cli.Format == "" {
+		cli.Format = formatJSON
+	}
+
+	switch cli.Format {
+	case formatJSON, formatNDJSON, formatICS, formatTimesheet:
+	default:
+		return fmt.Errorf("the format must be json, ndjson, ics or timesheet-csv")
+	}
+
+	filter, err := exportFilter(cli.From, cli.To)
+	if err != nil {
+		return err
+	}
+	if cli.Category != "" {
+		filter.Categories = []string{cli.Category}
+	}
+	filter.Tags = tags
+
+	logger, err := openLogger(cfg, cli.UseDatabase, cli.ConnString, cli.CsvPath)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logger.Close()
+
+	entries, err := logger.Query(filter)
+	if err != nil {
+		return fmt.Errorf("reading log: %w", err)
+	}
+
+	return writeExport(os.Stdout, cli.Format, entries, time.Now())
+}
+
+// exportFilter selects the finished sessions started between the dates, both
+// included, any bound left empty is open
+func exportFilter(fromArg, toArg string) (database.Filter, error) {
+	filter := database.Filter{State: database.Finished}
+
+	if fromArg != "" {
+		from, err := parseDate(fromArg, "start")
+		if err != nil {
+			return filter, err
+		}
+		filter.From = from.UnixMilli()
+	}
+
+	if toArg != "" {
+		to, err := parseDate(toArg, "end")
+		if err != nil {
+			return filter, err
+		}
+		filter.To = to.AddDate(0, 0, 1).UnixMilli()
+	}
+
+	if filter.To != 0 && filter.From >= filter.To {
+		return filter, fmt.Errorf("the start date is after the end date")
+	}
+
+	return filter, nil
+}
+
+func writeExport(w io.Writer, format string, entries []database.LogEntry, now time.Time) error {
+	switch format {
+	case formatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(newExportEntry(entry)); err != nil {
+				return fmt.Errorf("writing entry: %w", err)
+			}
+		}
+		return nil
+	case formatICS:
+		return writeICS(w, entries, now)
+	case formatTimesheet:
+		return writeTimesheet(w, entries)
+	default:
+		exported := make([]exportEntry, 0, len(entries))
+		for _, entry := range entries {
+			exported = append(exported, newExportEntry(entry))
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exported)
+	}
+}
+
+func newExportEntry(entry database.LogEntry) exportEntry {
+	duration := entryDuration(entry)
+	exported := exportEntry{
+		ID:          entry.ID,
+		Category:    entry.Category,
+		Description: entry.Description,
+		Tags:        entry.Tags,
+		Start:       time.UnixMilli(entry.InitTime).Format(time.RFC3339),
+		End:         time.UnixMilli(entry.EndTime).Format(time.RFC3339),
+		Duration:    ui.FormatClock(duration),
+		DurationMs:  duration.Milliseconds(),
+		Unlimited:   entry.Unlimited,
+		Outcome:     string(entry.Outcome),
+		Warnings:    entry.Warnings,
+		Hostname:    entry.Hostname,
+		User:        entry.User,
+	}
+
+	if entry.PlannedEndTime > 0 {
+		exported.PlannedEnd = time.UnixMilli(entry.PlannedEndTime).Format(time.RFC3339)
+		exported.Planned = ui.FormatClock(time.Duration(entry.PlannedEndTime-entry.InitTime) * time.Millisecond)
+	}
+
+	if entry.Overtime > 0 {
+		exported.Overtime = ui.FormatClock(time.Duration(entry.Overtime) * time.Millisecond)
+	}
+
+	for _, lap := range entry.Laps {
+		exported.Laps = append(exported.Laps, exportLap{
+			Time:    time.UnixMilli(lap.Time).Format(time.RFC3339),
+			Elapsed: ui.FormatClock(time.Duration(lap.Elapsed) * time.Millisecond),
+			Note:    lap.Note,
+		})
+	}
+
+	return exported
+}
+
+func entryDuration(entry database.LogEntry) time.Duration {
+	return time.Duration(max(0, entry.EndTime-entry.InitTime)) * time.Millisecond
+}
+
+// writeTimesheet writes a row per session with the local date and times, the
+// duration as hh:mm and in decimal hours
+func writeTimesheet(w io.Writer, entries []database.LogEntry) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "start", "end", "category", "description", "tags", "duration", "hours"})
+
+	for _, entry := range entries {
+		start, end := time.UnixMilli(entry.InitTime), time.UnixMilli(entry.EndTime)
+		duration := entryDuration(entry).Round(time.Minute)
+		writer.Write([]string{
+			start.Format(dateLayout),
+			start.Format("15:04"),
+			end.Format("15:04"),
+			entry.Category,
+			entry.Description,
+			strings.Join(entry.Tags, ","),
+			fmt.Sprintf("%d:%02d", int(duration.Hours()), int(duration.Minutes())%60),
+			fmt.Sprintf("%.2f", duration.Hours()),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeICS writes an iCalendar file with an event per session, the category
+// as the summary, the description as the body and the tags as categories
+func writeICS(w io.Writer, entries []database.LogEntry, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//just-notify//jn//EN",
+		"CALSCALE:GREGORIAN",
+	}
+
+	stamp := now.UTC().Format(icsTimeLayout)
+	for _, entry := range entries {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+icsUID(entry),
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+time.UnixMilli(entry.InitTime).UTC().Format(icsTimeLayout),
+			"DTEND:"+time.UnixMilli(entry.EndTime).UTC().Format(icsTimeLayout),
+			"SUMMARY:"+escapeICS(entry.Category),
+		)
+		if entry.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeICS(entry.Description))
+		}
+		if len(entry.Tags) > 0 {
+			tags := make([]string, len(entry.Tags))
+			for i, tag := range entry.Tags {
+				tags[i] = escapeICS(tag)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(tags, ","))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var calendar strings.Builder
+	for _, line := range lines {
+		calendar.WriteString(foldICS(line))
+		calendar.WriteString("\r\n")
+	}
+
+	_, err := io.WriteString(w, calendar.String())
+	return err
+}
+
+// icsUID identifies the event of a session, so importing it again updates it
+func icsUID(entry database.LogEntry) string {
+	if entry.ID != "" {
+		return entry.ID + "@just-notify"
+	}
+	return fmt.Sprintf("%d-%s@just-notify", entry.InitTime, escapeICS(entry.Category))
+}
+
+// escapeICS escapes a TEXT value of iCalendar (RFC 5545, 3.3.11)
+func escapeICS(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// foldICS splits the lines longer than 75 octets, the continuation lines
+// start with a space. Characters are never split.
+func foldICS(line string) string {
+	const limit = 75
+
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > limit {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	return folded.String()
+}
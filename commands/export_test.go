@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"just-notify/database"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func exportEntries() []database.LogEntry {
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	return []database.LogEntry{
+		{ID: "0b6f2f0e-5d1c-4c55-9a43-8d1f1e0c7a21", InitTime: start.UnixMilli(), EndTime: start.Add(90 * time.Minute).UnixMilli(),
+			PlannedEndTime: start.Add(time.Hour).UnixMilli(), Outcome: database.OutcomeCompleted, Category: "Focus",
+			Description: "Write the report, part 1; draft", Laps: []database.Lap{
+				{Time: start.Add(time.Hour).UnixMilli(), Elapsed: time.Hour.Milliseconds(), Note: "outline"}}},
+		{InitTime: start.Add(2 * time.Hour).UnixMilli(), EndTime: start.Add(2*time.Hour + 25*time.Minute).UnixMilli(),
+			Category: "Meeting", Overtime: 30000, Tags: []string{"client-acme", "billable"}},
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeExport(&out, formatJSON, exportEntries(), time.Now()); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+
+	var got []exportEntry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if got[0].Duration != "01:30:00" || got[0].DurationMs != (90*time.Minute).Milliseconds() {
+		t.Errorf("duration = %s, %d ms, want 01:30:00", got[0].Duration, got[0].DurationMs)
+	}
+	if _, err := time.Parse(time.RFC3339, got[0].Start); err != nil {
+		t.Errorf("start %q is not RFC 3339: %v", got[0].Start, err)
+	}
+	if len(got[0].Laps) != 1 || got[0].Laps[0].Elapsed != "01:00:00" || got[0].Laps[0].Note != "outline" {
+		t.Errorf("laps = %+v, want the outline lap at 01:00:00", got[0].Laps)
+	}
+	if len(got[1].Tags) != 2 || got[1].Tags[0] != "client-acme" {
+		t.Errorf("tags = %v, want [client-acme billable]", got[1].Tags)
+	}
+	if got[0].ID == "" || got[0].Planned != "01:00:00" || got[0].Outcome != "completed" {
+		t.Errorf("entry = %+v, want the id, 01:00:00 planned and completed", got[0])
+	}
+	if got[1].Overtime != "00:00:30" {
+		t.Errorf("overtime = %q, want 00:00:30", got[1].Overtime)
+	}
+
+	out.Reset()
+	if err := writeExport(&out, formatJSON, nil, time.Now()); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("empty export = %q, %v, want []", out.String(), err)
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeExport(&out, formatNDJSON, exportEntries(), time.Now()); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), out.String())
+	}
+	for _, line := range lines {
+		var entry exportEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Errorf("invalid line %q: %v", line, err)
+		}
+	}
+}
+
+func TestExportTimesheet(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeExport(&out, formatTimesheet, exportEntries(), time.Now()); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+
+	want := "date,start,end,category,description,tags,duration,hours\n" +
+		"2026-10-12,09:00,10:30,Focus,\"Write the report, part 1; draft\",,1:30,1.50\n" +
+		"2026-10-12,11:00,11:25,Meeting,,\"client-acme,billable\",0:25,0.42\n"
+	if out.String() != want {
+		t.Errorf("timesheet =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestExportICS(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	entries := exportEntries()
+	entries[1].Description = strings.Repeat("é", 60)
+
+	var out bytes.Buffer
+	if err := writeExport(&out, formatICS, entries, now); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+	ics := out.String()
+
+	start := time.UnixMilli(entries[0].InitTime).UTC().Format(icsTimeLayout)
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTAMP:20261019T080000Z\r\n",
+		"DTSTART:" + start + "\r\n",
+		"UID:0b6f2f0e-5d1c-4c55-9a43-8d1f1e0c7a21@just-notify\r\n",
+		"SUMMARY:Focus\r\n",
+		`DESCRIPTION:Write the report\, part 1\; draft` + "\r\n",
+		"SUMMARY:Meeting\r\n",
+		"CATEGORIES:client-acme,billable\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar misses %q:\n%s", want, ics)
+		}
+	}
+
+	if strings.Count(ics, "BEGIN:VEVENT") != 2 {
+		t.Errorf("want 2 events:\n%s", ics)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+	}
+}
+
+func TestExportFilter(t *testing.T) {
+	filter, err := exportFilter("2026-10-01", "2026-10-31")
+	if err != nil {
+		t.Fatalf("exportFilter() error = %v", err)
+	}
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local).UnixMilli()
+	to := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local).UnixMilli()
+	if filter.From != from || filter.To != to || filter.State != database.Finished {
+		t.Errorf("filter = %+v, want finished sessions from %d to %d", filter, from, to)
+	}
+
+	if filter, err := exportFilter("", ""); err != nil || filter.From != 0 || filter.To != 0 {
+		t.Errorf("open filter = %+v, %v, want no bounds", filter, err)
+	}
+
+	if _, err := exportFilter("2026-11-01", "2026-10-31"); err == nil {
+		t.Error("expected an error when the start date is after the end date")
+	}
+}
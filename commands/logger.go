@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"just-notify/database"
+)
+
+// openLogger opens the log used by the subcommands, flags take precedence
+// over the config like in the main command.
+func openLogger(cfg map[string]string, useDatabase bool, conn, csvPath string) (database.Logger, error) {
+	if !useDatabase {
+		useDatabase = cfg["USE_DATABASE"] == "true"
+	}
+
+	if useDatabase {
+		if conn == "" {
+			conn = cfg["CONN"]
+		}
+		return database.NewLogger(conn, true)
+	}
+
+	if csvPath == "" {
+		csvPath = cfg["CSV_PATH"]
+	}
+	return database.NewLogger(csvPath, false)
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/database"
+	"time"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+type migrateArgs struct {
+	From string `clap:"--from,-f"`
+	To   string `clap:"--to,-t"`
+}
+
+// Migrate copies the history from a log to another one, e.g. from the CSV
+// file to a database
+func Migrate(args []string, cfg map[string]string) error {
+	cli := &migrateArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing migrate arguments: %w", err)
+	}
+
+	if cli.From == "" || cli.To == "" {
+		return fmt.Errorf("Both --from and --to are required, e.g. --from csv:~/.jn.csv --to sqlite://~/.jn.db")
+	}
+
+	if database.SameLocation(cli.From, cli.To) {
+		return fmt.Errorf("The source and the destination are the same")
+	}
+
+	from, err := database.Open(cli.From)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", cli.From, err)
+	}
+	defer from.Close()
+
+	to, err := database.Open(cli.To)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", cli.To, err)
+	}
+	defer to.Close()
+
+	result, err := database.Migrate(from, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %s to %s: %d inserted, %d updated, %d unchanged, %d conflicted\n",
+		cli.From, cli.To, result.Inserted, result.Updated, result.Unchanged, result.Conflicted)
+
+	for _, entry := range result.Conflicts {
+		fmt.Printf("  conflict: %s %s, kept the destination\n",
+			time.UnixMilli(entry.InitTime).Format("2006-01-02 15:04:05"), entry.Category)
+	}
+
+	return nil
+}
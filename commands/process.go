@@ -1,14 +1,44 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
-const pidPathPattern = "/tmp/jn.%s.pid"
+const (
+	pidPathPattern  = "/tmp/jn.%s.pid"
+	taskPathPattern = "/tmp/jn.%s.task"
+)
+
+// TaskInfo is published by every running task so other jn processes can
+// show and control it
+type TaskInfo struct {
+	Pid         int    `json:"pid"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	InitTime    int64  `json:"init_time_ms"`
+	Planned     int64  `json:"planned_ms"` // Zero in unlimited mode
+	Elapsed     int64  `json:"elapsed_ms"` // Active time at UpdatedAt, pauses excluded
+	Paused      bool   `json:"paused"`
+	UpdatedAt   int64  `json:"updated_at_ms"`
+}
+
+// ElapsedNow returns the active time of the task at the current time
+func (t TaskInfo) ElapsedNow() time.Duration {
+	elapsed := time.Duration(t.Elapsed) * time.Millisecond
+	if !t.Paused {
+		elapsed += time.Since(time.UnixMilli(t.UpdatedAt))
+	}
+	return elapsed
+}
 
 func KillProcess(category string) (int, error) {
 	pidFile := fmt.Sprintf(pidPathPattern, strings.TrimSpace(category))
@@ -27,8 +57,9 @@ func KillProcess(category string) (int, error) {
 		return -1, fmt.Errorf("killing process: %w", err)
 	}
 
-	// Clean up PID file after successful termination
-	if err := os.Remove(pidFile); err != nil {
+	// Clean up PID file after successful termination, the process may have
+	// already removed it while shutting down
+	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
 		return pidNum, fmt.Errorf("removing PID file: %w", err)
 	}
 
@@ -48,3 +79,72 @@ func StorePID(category string) error {
 	return nil
 }
 
+// RemovePID cleans up the files of a task that finished
+func RemovePID(category string) {
+	category = strings.TrimSpace(category)
+	os.Remove(fmt.Sprintf(pidPathPattern, category))
+	os.Remove(fmt.Sprintf(taskPathPattern, category))
+}
+
+// StoreTask publishes the state of the running task, the file is replaced
+// atomically so readers never see a partial write.
+func StoreTask(info TaskInfo) error {
+	content, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("encoding task: %w", err)
+	}
+
+	taskFile := fmt.Sprintf(taskPathPattern, strings.TrimSpace(info.Category))
+	tmp := taskFile + ".tmp"
+
+	if err := os.WriteFile(tmp, content, 0640); err != nil {
+		return fmt.Errorf("writing task file: %w", err)
+	}
+
+	if err := os.Rename(tmp, taskFile); err != nil {
+		return fmt.Errorf("writing task file: %w", err)
+	}
+
+	return nil
+}
+
+// RunningTasks returns the published tasks whose process is still alive,
+// sorted by start time.
+func RunningTasks() ([]TaskInfo, error) {
+	files, err := filepath.Glob(fmt.Sprintf(taskPathPattern, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing tasks: %w", err)
+	}
+
+	var tasks []TaskInfo
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var info TaskInfo
+		if err := json.Unmarshal(content, &info); err != nil {
+			continue
+		}
+
+		if !processAlive(info.Pid) {
+			continue
+		}
+
+		tasks = append(tasks, info)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].InitTime < tasks[j].InitTime
+	})
+
+	return tasks, nil
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(pid, syscall.Signal(0)) == nil
+}
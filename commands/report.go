@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/config"
+	"just-notify/database"
+	"just-notify/ui"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	reportBarWidth = 30
+)
+
+// Periods of the report
+const (
+	periodDay   = "day"
+	periodWeek  = "week"
+	periodMonth = "month"
+)
+
+type reportArgs struct {
+	UseDatabase bool   `clap:"--database,-d"`
+	ConnString  string `clap:"--conn,-s"`
+	CsvPath     string `clap:"--csvpath,-C"`
+	From        string `clap:"--from,-f"`
+	To          string `clap:"--to,-t"`
+	By          string `clap:"--by,-b"`
+	Category    string `clap:"--cat,-c"`
+}
+
+// reportRow aggregates the finished sessions of a category or a period
+type reportRow struct {
+	Label    string
+	Sessions int
+	Total    time.Duration
+}
+
+func (r reportRow) Average() time.Duration {
+	if r.Sessions == 0 {
+		return 0
+	}
+	return r.Total / time.Duration(r.Sessions)
+}
+
+// Report prints the time logged by category and by period over a date range
+func Report(args []string, cfg map[string]string) error {
+	cli := &reportArgs{}
+	args, tags, err := config.ExtractTags(args, cli)
+	if err != nil {
+		return err
+	}
+
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing report arguments: %w", err)
+	}
+
+	if cli.By == "" {
+		cli.By = periodDay
+	}
+
+	from, to, err := reportRange(cli.From, cli.To, cli.By, time.Now())
+	if err != nil {
+		return err
+	}
+
+	logger, err := openLogger(cfg, cli.UseDatabase, cli.ConnString, cli.CsvPath)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logger.Close()
+
+	filter := database.Filter{From: from.UnixMilli(), To: to.UnixMilli(), State: database.Finished, Tags: tags}
+	if cli.Category != "" {
+		filter.Categories = []string{cli.Category}
+	}
+
+	entries, err := logger.Query(filter)
+	if err != nil {
+		return fmt.Errorf("reading log: %w", err)
+	}
+
+	theme, err := ui.LoadTheme(cfg)
+	if err != nil {
+		return fmt.Errorf("loading progress theme: %w", err)
+	}
+
+	categories, periods := summarize(entries, from, to, cli.By)
+
+	title := fmt.Sprintf("Report %s to %s by %s", from.Format(dateLayout), to.AddDate(0, 0, -1).Format(dateLayout), cli.By)
+	if cli.Category != "" {
+		title += ", category " + cli.Category
+	}
+	if len(tags) > 0 {
+		title += ", tagged " + strings.Join(tags, ", ")
+	}
+	fmt.Println(title)
+
+	if len(categories) == 0 {
+		fmt.Println("\nNothing logged in this range")
+		return nil
+	}
+
+	printReportTable("Category", categories, theme.Fill)
+	printReportTable(strings.ToUpper(cli.By[:1])+cli.By[1:], periods, theme.Fill)
+
+	// Entries logged by older versions have no outcome nor user
+	outcomes := groupBy(entries, from, to, entryOutcome)
+	if len(outcomes) > 1 || (len(outcomes) == 1 && outcomes[0].Label != unknownLabel) {
+		printReportTable("Outcome", outcomes, theme.Fill)
+	}
+
+	if machines := groupBy(entries, from, to, entryMachine); len(machines) > 1 {
+		printReportTable("User", machines, theme.Fill)
+	}
+
+	var total reportRow
+	for _, row := range categories {
+		total.Sessions += row.Sessions
+		total.Total += row.Total
+	}
+	fmt.Printf("\nTotal: %d sessions, %s, %s on average\n", total.Sessions, ui.FormatDuration(total.Total),
+		ui.FormatDuration(total.Average()))
+
+	if planned, actual := plannedTime(entries, from, to); planned.Sessions > 0 {
+		fmt.Printf("Planned: %s for %d timed sessions, actual %s (%.0f%%)\n", ui.FormatDuration(planned.Total),
+			planned.Sessions, ui.FormatDuration(actual), float64(actual)/float64(planned.Total)*100)
+	}
+
+	return nil
+}
+
+// reportRange returns the start of the first day and the end of the last day
+// of the report. The default range covers the last 7 days, 4 weeks or 3
+// months up to today.
+func reportRange(fromArg, toArg, by string, now time.Time) (time.Time, time.Time, error) {
+	switch by {
+	case periodDay, periodWeek, periodMonth:
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("the period must be day, week or month")
+	}
+
+	to := startOfDay(now)
+	if toArg != "" {
+		var err error
+		if to, err = parseDate(toArg, "end"); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	var from time.Time
+	if fromArg != "" {
+		var err error
+		if from, err = parseDate(fromArg, "start"); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	} else {
+		switch by {
+		case periodDay:
+			from = to.AddDate(0, 0, -6)
+		case periodWeek:
+			from = periodStart(to, periodWeek).AddDate(0, 0, -21)
+		case periodMonth:
+			from = periodStart(to, periodMonth).AddDate(0, -2, 0)
+		}
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the start date is after the end date")
+	}
+
+	// The end date is included
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// parseDate parses a YYYY-MM-DD date at midnight in the local time zone
+func parseDate(value, name string) (time.Time, error) {
+	date, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s date %q, expected YYYY-MM-DD", name, value)
+	}
+	return date, nil
+}
+
+// summarize aggregates the finished entries started in [from, to) by category,
+// sorted by total time, and by period, in order and including the empty ones.
+func summarize(entries []database.LogEntry, from, to time.Time, by string) ([]reportRow, []reportRow) {
+	periods := make(map[string]*reportRow)
+
+	var periodOrder []string
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := periodLabel(day, by)
+		if _, ok := periods[key]; !ok {
+			periods[key] = &reportRow{Label: key}
+			periodOrder = append(periodOrder, key)
+		}
+	}
+
+	for _, entry := range entries {
+		start, duration, ok := session(entry, from, to)
+		if !ok {
+			continue
+		}
+
+		period := periods[periodLabel(start, by)]
+		period.Sessions++
+		period.Total += duration
+	}
+
+	byPeriod := make([]reportRow, 0, len(periodOrder))
+	for _, key := range periodOrder {
+		byPeriod = append(byPeriod, *periods[key])
+	}
+
+	categories := groupBy(entries, from, to, func(entry database.LogEntry) string { return entry.Category })
+
+	return categories, byPeriod
+}
+
+// groupBy aggregates the finished entries started in [from, to) by the label
+// of each, sorted by total time
+func groupBy(entries []database.LogEntry, from, to time.Time, label func(database.LogEntry) string) []reportRow {
+	groups := make(map[string]*reportRow)
+
+	for _, entry := range entries {
+		_, duration, ok := session(entry, from, to)
+		if !ok {
+			continue
+		}
+
+		key := label(entry)
+		group, ok := groups[key]
+		if !ok {
+			group = &reportRow{Label: key}
+			groups[key] = group
+		}
+		group.Sessions++
+		group.Total += duration
+	}
+
+	rows := make([]reportRow, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total != rows[j].Total {
+			return rows[i].Total > rows[j].Total
+		}
+		return rows[i].Label < rows[j].Label
+	})
+
+	return rows
+}
+
+// session returns the start and the duration of an entry, false when it is
+// not finished or not started in [from, to)
+func session(entry database.LogEntry, from, to time.Time) (time.Time, time.Duration, bool) {
+	start := time.UnixMilli(entry.InitTime)
+	if entry.EndTime <= entry.InitTime || start.Before(from) || !start.Before(to) {
+		return start, 0, false
+	}
+	return start, time.Duration(entry.EndTime-entry.InitTime) * time.Millisecond, true
+}
+
+const unknownLabel = "unknown"
+
+func entryOutcome(entry database.LogEntry) string {
+	if entry.Outcome == "" {
+		return unknownLabel
+	}
+	return string(entry.Outcome)
+}
+
+// entryMachine labels an entry with its user and host, user@host
+func entryMachine(entry database.LogEntry) string {
+	switch {
+	case entry.User != "" && entry.Hostname != "":
+		return entry.User + "@" + entry.Hostname
+	case entry.User != "" || entry.Hostname != "":
+		return entry.User + entry.Hostname
+	default:
+		return unknownLabel
+	}
+}
+
+// plannedTime sums the planned time of the sessions with a deadline, and
+// their actual time
+func plannedTime(entries []database.LogEntry, from, to time.Time) (reportRow, time.Duration) {
+	var planned reportRow
+	var actual time.Duration
+
+	for _, entry := range entries {
+		_, duration, ok := session(entry, from, to)
+		if !ok || entry.PlannedEndTime <= entry.InitTime {
+			continue
+		}
+
+		planned.Sessions++
+		planned.Total += time.Duration(entry.PlannedEndTime-entry.InitTime) * time.Millisecond
+		actual += duration
+	}
+
+	return planned, actual
+}
+
+// periodLabel names the period of t: 2006-01-02, 2006-W01 (ISO week) or 2006-01
+func periodLabel(t time.Time, by string) string {
+	switch by {
+	case periodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case periodMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format(dateLayout)
+	}
+}
+
+// periodStart returns the first day of the period of t, weeks start on Monday
+func periodStart(t time.Time, by string) time.Time {
+	day := startOfDay(t)
+	switch by {
+	case periodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case periodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func printReportTable(title string, rows []reportRow, fill string) {
+	var longest time.Duration
+	for _, row := range rows {
+		longest = max(longest, row.Total)
+	}
+
+	fmt.Printf("\n%-16s %8s %9s %9s\n", title, "Sessions", "Total", "Average")
+	for _, row := range rows {
+		bar := ""
+		if longest > 0 {
+			cells := int(float64(row.Total) / float64(longest) * reportBarWidth)
+			if row.Total > 0 {
+				cells = max(cells, 1)
+			}
+			bar = strings.Repeat(fill, cells)
+		}
+
+		fmt.Printf("%-16s %8d %9s %9s  %s\n", truncateLabel(row.Label, 16), row.Sessions,
+			ui.FormatDuration(row.Total), ui.FormatDuration(row.Average()), bar)
+	}
+}
+
+func truncateLabel(label string, width int) string {
+	runes := []rune(label)
+	if len(runes) <= width {
+		return label
+	}
+	return string(runes[:width-1]) + "…"
+}
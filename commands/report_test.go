@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"just-notify/database"
+	"testing"
+	"time"
+)
+
+func TestReportRange(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, 10, 14, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		from, to string
+		by       string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{"Last 7 days", "", "", periodDay, "2026-10-08", "2026-10-15", false},
+		{"Last 4 weeks", "", "", periodWeek, "2026-09-21", "2026-10-15", false},
+		{"Last 3 months", "", "", periodMonth, "2026-08-01", "2026-10-15", false},
+		{"Explicit range", "2026-01-01", "2026-01-31", periodDay, "2026-01-01", "2026-02-01", false},
+		{"Start after end", "2026-02-01", "2026-01-31", periodDay, "", "", true},
+		{"Invalid date", "01/02/2026", "", periodDay, "", "", true},
+		{"Invalid period", "", "", "year", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := reportRange(tt.from, tt.to, tt.by, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reportRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := from.Format(dateLayout); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format(dateLayout); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	at := func(day, hour int) int64 {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local).UnixMilli()
+	}
+	hour := time.Hour.Milliseconds()
+
+	entries := []database.LogEntry{
+		{InitTime: at(12, 9), EndTime: at(12, 9) + hour, Category: "work"},
+		{InitTime: at(12, 14), EndTime: at(12, 14) + 2*hour, Category: "work"},
+		{InitTime: at(13, 9), EndTime: at(13, 9) + hour/2, Category: "rest"},
+		{InitTime: at(14, 9), Category: "work"},                            // Not finished
+		{InitTime: at(20, 9), EndTime: at(20, 9) + hour, Category: "work"}, // Out of range
+	}
+
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 3)
+
+	categories, periods := summarize(entries, from, to, periodDay)
+
+	if len(categories) != 2 {
+		t.Fatalf("got %d categories, want 2: %+v", len(categories), categories)
+	}
+	if categories[0].Label != "work" || categories[0].Sessions != 2 || categories[0].Total != 3*time.Hour {
+		t.Errorf("work = %+v, want 2 sessions and 3h", categories[0])
+	}
+	if categories[0].Average() != 90*time.Minute {
+		t.Errorf("work average = %s, want 1h30m", categories[0].Average())
+	}
+	if categories[1].Label != "rest" || categories[1].Total != 30*time.Minute {
+		t.Errorf("rest = %+v, want 1 session and 30m", categories[1])
+	}
+
+	wantPeriods := []reportRow{
+		{Label: "2026-10-12", Sessions: 2, Total: 3 * time.Hour},
+		{Label: "2026-10-13", Sessions: 1, Total: 30 * time.Minute},
+		{Label: "2026-10-14"},
+	}
+	if len(periods) != len(wantPeriods) {
+		t.Fatalf("got %d periods, want %d: %+v", len(periods), len(wantPeriods), periods)
+	}
+	for i, want := range wantPeriods {
+		if periods[i] != want {
+			t.Errorf("period %d = %+v, want %+v", i, periods[i], want)
+		}
+	}
+
+	_, weeks := summarize(entries, from, to, periodWeek)
+	if len(weeks) != 1 || weeks[0].Label != "2026-W42" || weeks[0].Sessions != 3 {
+		t.Errorf("weeks = %+v, want a single 2026-W42 with 3 sessions", weeks)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	at := start.UnixMilli()
+	hour := time.Hour.Milliseconds()
+
+	entries := []database.LogEntry{
+		{InitTime: at, EndTime: at + hour, PlannedEndTime: at + hour, Outcome: database.OutcomeCompleted,
+			User: "ana", Hostname: "laptop"},
+		{InitTime: at + 2*hour, EndTime: at + 2*hour + hour/2, PlannedEndTime: at + 3*hour,
+			Outcome: database.OutcomeKilled, User: "ana", Hostname: "desktop"},
+		{InitTime: at + 4*hour, EndTime: at + 5*hour}, // Logged by an older version
+	}
+
+	from, to := startOfDay(start), startOfDay(start).AddDate(0, 0, 1)
+
+	outcomes := groupBy(entries, from, to, entryOutcome)
+	want := []reportRow{
+		{Label: "completed", Sessions: 1, Total: time.Hour},
+		{Label: unknownLabel, Sessions: 1, Total: time.Hour},
+		{Label: "killed", Sessions: 1, Total: 30 * time.Minute},
+	}
+	if len(outcomes) != len(want) {
+		t.Fatalf("outcomes = %+v, want %+v", outcomes, want)
+	}
+	for i := range want {
+		if outcomes[i] != want[i] {
+			t.Errorf("outcome %d = %+v, want %+v", i, outcomes[i], want[i])
+		}
+	}
+
+	machines := groupBy(entries, from, to, entryMachine)
+	if len(machines) != 3 || machines[0].Label != "ana@laptop" {
+		t.Errorf("machines = %+v, want ana@laptop, unknown and ana@desktop", machines)
+	}
+
+	planned, actual := plannedTime(entries, from, to)
+	if planned.Sessions != 2 || planned.Total != 2*time.Hour || actual != 90*time.Minute {
+		t.Errorf("planned = %+v, actual = %s, want 2 sessions, 2h planned and 1h30m actual", planned, actual)
+	}
+}
@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"just-notify/ui"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+const defaultStatusFormat = "{{.Category}} {{if .Planned}}{{clock .Remaining}}{{else}}{{clock .Elapsed}}{{end}}" +
+	"{{if .Paused}} (paused){{end}}"
+
+// TimerStatus is the state of a running timer, available to the status templates
+type TimerStatus struct {
+	Category    string
+	Description string
+	Start       time.Time
+	End         time.Time     // Expected end if it is not paused again, zero in unlimited mode
+	Planned     time.Duration // Zero in unlimited mode
+	Elapsed     time.Duration
+	Remaining   time.Duration // Zero in unlimited mode
+	Progress    float64       // Percentage, zero in unlimited mode
+	Paused      bool
+}
+
+// statusJSON is the shape of the timers in status.json
+type statusJSON struct {
+	Category    string  `json:"category"`
+	Description string  `json:"description"`
+	InitTime    int64   `json:"init_time_ms"`
+	EndTime     int64   `json:"end_time_ms"`
+	Planned     int64   `json:"planned_ms"`
+	Elapsed     int64   `json:"elapsed_ms"`
+	Remaining   int64   `json:"remaining_ms"`
+	Progress    float64 `json:"progress"`
+	Paused      bool    `json:"paused"`
+	Text        string  `json:"text"` // Rendered with STATUS_FORMAT
+}
+
+var statusFuncs = template.FuncMap{
+	"clock": ui.FormatClock,
+}
+
+type statusArgs struct {
+	Format   string `clap:"--format,-f"`
+	Category string `clap:"--cat,-c"`
+	JSON     bool   `clap:"--json,-j"`
+}
+
+// Status prints a line for every running timer, for status bar scripts
+func Status(args []string, cfg map[string]string) error {
+	cli := &statusArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing status arguments: %w", err)
+	}
+
+	if cli.Format == "" {
+		cli.Format = cfg["STATUS_FORMAT"]
+	}
+
+	tmpl, err := NewStatusTemplate(cli.Format)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := CurrentStatus()
+	if err != nil {
+		return err
+	}
+
+	var selected []TimerStatus
+	for _, status := range statuses {
+		if cli.Category == "" || status.Category == cli.Category {
+			selected = append(selected, status)
+		}
+	}
+
+	if cli.JSON {
+		content, err := encodeStatus(selected, tmpl)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	for _, status := range selected {
+		text, err := renderStatus(tmpl, status)
+		if err != nil {
+			return err
+		}
+		fmt.Println(text)
+	}
+
+	return nil
+}
+
+// NewStatusTemplate parses the format of the status lines, an empty format
+// uses the default one
+func NewStatusTemplate(format string) (*template.Template, error) {
+	if format == "" {
+		format = defaultStatusFormat
+	}
+
+	tmpl, err := template.New("status").Funcs(statusFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("parsing status format: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// CurrentStatus returns the state of the running timers, sorted by start time
+func CurrentStatus() ([]TimerStatus, error) {
+	tasks, err := RunningTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]TimerStatus, 0, len(tasks))
+	for _, task := range tasks {
+		state := ui.TimerState{
+			Elapsed: task.ElapsedNow().Round(time.Second),
+			Planned: time.Duration(task.Planned) * time.Millisecond,
+			Paused:  task.Paused,
+		}
+
+		status := TimerStatus{
+			Category:    task.Category,
+			Description: task.Description,
+			Start:       time.UnixMilli(task.InitTime),
+			Planned:     state.Planned,
+			Elapsed:     state.Elapsed,
+			Remaining:   state.Remaining(),
+			Progress:    state.Progress() * 100,
+			Paused:      task.Paused,
+		}
+		if state.Planned != 0 {
+			status.End = time.Now().Add(status.Remaining)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// StatusDir returns the directory of the status files, in the user runtime
+// directory when there is one
+func StatusDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "jn")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("jn-%d", os.Getuid()))
+}
+
+// WriteStatusFiles replaces status.json and status.txt with the running
+// timers. Every running task writes the same content, so concurrent writers
+// are harmless as long as each file is replaced atomically.
+func WriteStatusFiles(tmpl *template.Template) error {
+	statuses, err := CurrentStatus()
+	if err != nil {
+		return err
+	}
+
+	dir := StatusDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("creating status directory: %w", err)
+	}
+
+	content, err := encodeStatus(statuses, tmpl)
+	if err != nil {
+		return err
+	}
+	if err := writeAtomic(filepath.Join(dir, "status.json"), append(content, '\n')); err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, status := range statuses {
+		text, err := renderStatus(tmpl, status)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, text)
+	}
+
+	text := strings.Join(lines, "\n")
+	if text != "" {
+		text += "\n"
+	}
+
+	return writeAtomic(filepath.Join(dir, "status.txt"), []byte(text))
+}
+
+func encodeStatus(statuses []TimerStatus, tmpl *template.Template) ([]byte, error) {
+	entries := make([]statusJSON, 0, len(statuses))
+	for _, status := range statuses {
+		text, err := renderStatus(tmpl, status)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := statusJSON{
+			Category:    status.Category,
+			Description: status.Description,
+			InitTime:    status.Start.UnixMilli(),
+			Planned:     status.Planned.Milliseconds(),
+			Elapsed:     status.Elapsed.Milliseconds(),
+			Remaining:   status.Remaining.Milliseconds(),
+			Progress:    status.Progress,
+			Paused:      status.Paused,
+			Text:        text,
+		}
+		if !status.End.IsZero() {
+			entry.EndTime = status.End.UnixMilli()
+		}
+
+		entries = append(entries, entry)
+	}
+
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("encoding status: %w", err)
+	}
+
+	return content, nil
+}
+
+func renderStatus(tmpl *template.Template, status TimerStatus) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, status); err != nil {
+		return "", fmt.Errorf("rendering status: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// writeAtomic writes through a temporary file in the same directory, so
+// readers never see a partial write
+func writeAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusTemplate(t *testing.T) {
+	limited := TimerStatus{
+		Category:  "Focus",
+		Planned:   25 * time.Minute,
+		Elapsed:   10 * time.Minute,
+		Remaining: 15 * time.Minute,
+		Progress:  40,
+	}
+	unlimited := TimerStatus{Category: "Read", Elapsed: 90 * time.Second, Paused: true}
+
+	tests := []struct {
+		name    string
+		format  string
+		status  TimerStatus
+		want    string
+		wantErr bool
+	}{
+		{"Default limited", "", limited, "Focus 00:15:00", false},
+		{"Default unlimited and paused", "", unlimited, "Read 00:01:30 (paused)", false},
+		{"Custom", "{{.Category}} {{.Remaining}}", limited, "Focus 15m0s", false},
+		{"Progress", `{{printf "%.0f" .Progress}}%`, limited, "40%", false},
+		{"Invalid", "{{.Category", limited, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewStatusTemplate(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStatusTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := renderStatus(tmpl, tt.status)
+			if err != nil {
+				t.Fatalf("renderStatus() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+// Subcommand runs a jn subcommand with the arguments that follow its name
+type Subcommand func(args []string, cfg map[string]string) error
+
+var Subcommands = map[string]Subcommand{
+	"ack":       Ack,
+	"csv":       CSV,
+	"dashboard": Dashboard,
+	"db":        DB,
+	"export":    Export,
+	"lap":       Lap,
+	"migrate":   Migrate,
+	"report":    Report,
+	"status":    Status,
+	"sync":      Sync,
+}
+
+type ackArgs struct {
+	Category string `clap:"--cat,-c"`
+}
+
+// Ack stops the reminders of a task running in nag mode
+func Ack(args []string, cfg map[string]string) error {
+	cli := &ackArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing ack arguments: %w", err)
+	}
+
+	if cli.Category == "" {
+		cli.Category = cfg["DEFAULT_CATEGORY"]
+	}
+
+	if cli.Category == "" {
+		return fmt.Errorf("A category is required to acknowledge a task")
+	}
+
+	if err := SendControl(cli.Category, "ack"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Task %s acknowledged\n", cli.Category)
+	return nil
+}
+
+type lapArgs struct {
+	Category string   `clap:"--cat,-c"`
+	Note     []string `clap:"trailing"`
+}
+
+// Lap records a lap with an optional note in the running task of a category
+func Lap(args []string, cfg map[string]string) error {
+	cli := &lapArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing lap arguments: %w", err)
+	}
+
+	if cli.Category == "" {
+		cli.Category = cfg["DEFAULT_CATEGORY"]
+	}
+
+	if cli.Category == "" {
+		return fmt.Errorf("A category is required to record a lap")
+	}
+
+	// Commands are queued one per line
+	note := strings.Join(strings.Fields(strings.Join(cli.Note, " ")), " ")
+
+	if err := SendControl(cli.Category, strings.TrimSpace("lap "+note)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Lap recorded for %s\n", cli.Category)
+	return nil
+}
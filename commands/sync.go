@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+	"just-notify/database"
+
+	"github.com/fred1268/go-clap/clap"
+)
+
+type syncArgs struct {
+	ConnString string `clap:"--conn,-s"`
+	SpoolPath  string `clap:"--spool,-p"`
+}
+
+// Sync inserts in the database the entries spooled while it was unreachable,
+// jn also does it when a timer starts
+func Sync(args []string, cfg map[string]string) error {
+	cli := &syncArgs{}
+	if _, err := clap.Parse(args, cli); err != nil {
+		return fmt.Errorf("parsing sync arguments: %w", err)
+	}
+
+	if cli.ConnString == "" {
+		cli.ConnString = cfg["CONN"]
+	}
+
+	if cli.ConnString == "" {
+		return fmt.Errorf("No database configured, set CONN in ~/.jnconfig or use --conn")
+	}
+
+	if cli.SpoolPath == "" {
+		cli.SpoolPath = cfg["SPOOL_PATH"]
+	}
+
+	spool, err := database.NewSpool(cli.SpoolPath)
+	if err != nil {
+		return fmt.Errorf("opening spool: %w", err)
+	}
+
+	logger := database.NewSpooled(cli.ConnString, spool)
+	defer logger.Close()
+
+	result, err := logger.Sync()
+	if result.Synced > 0 || result.Stale > 0 {
+		fmt.Printf("Synced %s: %d inserted, %d stale dropped\n", spool.Path(), result.Synced, result.Stale)
+	} else if err == nil {
+		fmt.Println("Nothing to sync")
+	}
+
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/fred1268/go-clap/clap"
@@ -20,6 +21,17 @@ type ArgsCli struct {
 	Headless    bool   `clap:"--headless,-H"`
 	CsvPath     string `clap:"--csvpath,-C"`
 	Kill        bool   `clap:"--kill,-k"`
+	Warn        string `clap:"--warn,-w"`
+	Nag         string `clap:"--nag,-g"`
+	TitleTmpl   string `clap:"--title-template"`
+	BodyTmpl    string `clap:"--body-template"`
+	Notifiers   string `clap:"--notifiers"`
+	Output      string `clap:"--output,-o"`
+	StatusFile  bool   `clap:"--status-file,-S"`
+	Big         bool   `clap:"--big,-b"`
+
+	// --tag,-T can be repeated, which clap rejects, see ExtractTags
+	Tags []string
 }
 
 const (
@@ -66,10 +78,17 @@ func ParseArgs(cfg map[string]string) (*ArgsCli, error) {
 	args := os.Args
 	cli := &ArgsCli{}
 
-	var err error
 	var results *clap.Results
 
-	if results, err = clap.Parse(args[1:], cli); err != nil {
+	rest, tags, err := ExtractTags(args[1:], cli)
+	if err != nil {
+		fmt.Printf("Error: %s\n\n", err)
+		PrintUsage()
+		os.Exit(1)
+	}
+	cli.Tags = tags
+
+	if results, err = clap.Parse(rest, cli); err != nil {
 		if len(results.Mandatory) > 0 {
 			for i, mandatory := range results.Mandatory {
 				// For some reason each mandatory is repeated
@@ -96,6 +115,31 @@ func ParseArgs(cfg map[string]string) (*ArgsCli, error) {
 		cli.Notif = cfg["DEFAULT_NOTIFICATION"]
 	}
 
+	// The configured warnings and nag only apply to scheduled timers
+	if cli.Warn == "" && !cli.Unlimited {
+		cli.Warn = cfg["WARN"]
+	}
+
+	if cli.Nag == "" && !cli.Unlimited {
+		cli.Nag = cfg["NAG"]
+	}
+
+	if cli.TitleTmpl == "" {
+		cli.TitleTmpl = cfg["NOTIFY_TITLE_TEMPLATE"]
+	}
+
+	if cli.BodyTmpl == "" {
+		cli.BodyTmpl = cfg["NOTIFY_BODY_TEMPLATE"]
+	}
+
+	if cli.Notifiers == "" {
+		cli.Notifiers = cfg["NOTIFIERS"]
+	}
+
+	if cli.Output == "" {
+		cli.Output = cfg["OUTPUT"]
+	}
+
 	if !cli.UseDatabase {
 		cli.UseDatabase = cfg["USE_DATABASE"] == "true"
 	}
@@ -104,6 +148,10 @@ func ParseArgs(cfg map[string]string) (*ArgsCli, error) {
 		cli.Headless = cfg["HEADLESS"] == "true"
 	}
 
+	if !cli.StatusFile {
+		cli.StatusFile = cfg["STATUS_FILE"] == "true"
+	}
+
 	if cli.Category == "" {
 		cli.Category = defaultCategory
 	}
@@ -115,6 +163,92 @@ func ParseArgs(cfg map[string]string) (*ArgsCli, error) {
 	return cli, nil
 }
 
+// ExtractTags takes the --tag and -T options out of the arguments, clap
+// rejects repeated options. A value can hold several tags separated by
+// commas, duplicates are dropped. cli is the clap struct the rest is parsed
+// into, the values of its options are never taken for tags. The arguments
+// after -- are left as they are.
+func ExtractTags(args []string, cli any) ([]string, []string, error) {
+	var rest, tags []string
+	seen := make(map[string]bool)
+	takesValue := valueOptions(cli)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		var value string
+		switch {
+		case arg == "--":
+			return append(rest, args[i:]...), tags, nil
+		case takesValue[arg]:
+			// Kept for clap even when it looks like an option, e.g. -l -T,
+			// clap reports the missing value
+			rest = append(rest, arg)
+			if i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		case arg == "--tag" || arg == "-T":
+			i++
+			if i >= len(args) || strings.HasPrefix(args[i], "-") {
+				return nil, nil, fmt.Errorf("%s requires a value", arg)
+			}
+			value = args[i]
+		case strings.HasPrefix(arg, "--tag=") || strings.HasPrefix(arg, "-T="):
+			_, value, _ = strings.Cut(arg, "=")
+			if value == "" {
+				return nil, nil, fmt.Errorf("%s requires a value", strings.TrimSuffix(arg, "="))
+			}
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return rest, tags, nil
+}
+
+// valueOptions returns the names of the options of a clap struct which are
+// followed by a value, every option but the booleans
+func valueOptions(cli any) map[string]bool {
+	options := make(map[string]bool)
+
+	t := reflect.TypeOf(cli)
+	if t == nil {
+		return options
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return options
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("clap")
+		if tag == "" || field.Type.Kind() == reflect.Bool {
+			continue
+		}
+		for _, name := range strings.Split(tag, ",") {
+			if name = strings.TrimSpace(name); strings.HasPrefix(name, "-") {
+				options[name] = true
+			}
+		}
+	}
+
+	return options
+}
+
 func ValidateArgs(args *ArgsCli, cfg map[string]string) error {
 	if !args.Kill && args.Time == "" && !args.Unlimited {
 		return fmt.Errorf("\nERROR: Time argument is required")
@@ -127,6 +261,26 @@ func ValidateArgs(args *ArgsCli, cfg map[string]string) error {
 		return fmt.Errorf("\nERROR: Category is required")
 	}
 
+	if args.Warn != "" && args.Unlimited {
+		return fmt.Errorf("\nERROR: Warnings require a scheduled time")
+	}
+
+	if args.Nag != "" && args.Unlimited {
+		return fmt.Errorf("\nERROR: Nag mode requires a scheduled time")
+	}
+
+	if args.Output != "" && args.Output != "human" && args.Output != "json" {
+		return fmt.Errorf("\nERROR: Output must be human or json")
+	}
+
+	if args.Big && args.Unlimited {
+		return fmt.Errorf("\nERROR: The big clock requires a scheduled time")
+	}
+
+	if args.Big && args.Output == "json" {
+		return fmt.Errorf("\nERROR: The big clock cannot be used with the JSON output")
+	}
+
 	if args.UseDatabase {
 		if args.ConnString == "" && cfg["CONN"] == "" {
 			return fmt.Errorf("Database enabled but no connection string provided")
@@ -147,15 +301,54 @@ func PrintUsage() {
 	fmt.Printf("  -c, --cat         Category of the task (e.g., 'work')\n")
 	fmt.Printf("  -n, --notif       Notification title to be shown\n")
 	fmt.Printf("  -l, --description Optional details of the task\n")
+	fmt.Printf("  -T, --tag         Tag of the task, repeatable or comma separated (e.g., 'billable')\n")
 	fmt.Printf("  -d, --database    Enable SQL database usage\n")
 	fmt.Printf("  -s, --conn        Database connection string (required if --database is set)\n")
 	fmt.Printf("  -u, --unlimited   Set unlimited time\n")
 	fmt.Printf("  -H, --headless    Disable notifications\n")
 	fmt.Printf("  -C, --csvpath     CSV file path (ignored if database is enabled)\n")
 	fmt.Printf("  -k, --kill        Kill a task (requires category)\n")
+	fmt.Printf("  -w, --warn        Pre-alerts before the deadline, comma separated\n")
+	fmt.Printf("                     Format: <n>m/<n>h/<n>s left, or <n>%% of the time elapsed\n")
+	fmt.Printf("  -g, --nag         Repeat the notification every interval until acknowledged\n")
+	fmt.Printf("  --title-template  Go template for the notification title\n")
+	fmt.Printf("  --body-template   Go template for the notification message\n")
+	fmt.Printf("                     Fields: .Title .Category .Description .Planned .Elapsed\n")
+	fmt.Printf("                     .Overtime .Start .End .Today, functions: clock, round\n")
+	fmt.Printf("  --notifiers       Notifiers tried in order until one succeeds\n")
+	fmt.Printf("                     (dbus, notify-send, terminal-notifier, osascript, terminal,\n")
+	fmt.Printf("                     tty, bell, stderr)\n")
+	fmt.Printf("  -o, --output      Output format: human (default) or json, one event per line\n")
+	fmt.Printf("  -b, --big         Show the remaining time in large digits, full screen\n")
+	fmt.Printf("  -S, --status-file Keep $XDG_RUNTIME_DIR/jn/status.json and status.txt updated\n")
 	fmt.Println("\nConfiguration:")
 	fmt.Printf("  Config file: ~/.jnconfig\n")
 	fmt.Printf("  Supported config keys: DEFAULT_CATEGORY, CSV_PATH, DEFAULT_NOTIFICATION,\n")
-	fmt.Printf("                        USE_DATABASE, HEADLESS, CONN\n")
+	fmt.Printf("                        USE_DATABASE, HEADLESS, CONN, WARN, NAG,\n")
+	fmt.Printf("                        NOTIFY_TITLE_TEMPLATE, NOTIFY_BODY_TEMPLATE,\n")
+	fmt.Printf("                        HOOKS_DIR, HOOK_ON_START, HOOK_ON_FINISH,\n")
+	fmt.Printf("                        HOOK_ON_CANCEL, HOOK_TIMEOUT, NOTIFIERS, TERMINAL_OSC,\n")
+	fmt.Printf("                        BROADCAST_SYSLOG, PROGRESS_THEME, PROGRESS_FILL,\n")
+	fmt.Printf("                        PROGRESS_EMPTY, PROGRESS_LEFT, PROGRESS_RIGHT,\n")
+	fmt.Printf("                        PROGRESS_LAYOUT, PROGRESS_COLOR, PROGRESS_COLORS,\n")
+	fmt.Printf("                        CATEGORY_COLORS, OUTPUT, STATUS_FILE, STATUS_FORMAT,\n")
+	fmt.Printf("                        SPOOL_PATH\n")
+	fmt.Println("\nCommands:")
+	fmt.Printf("  ack -c <category>  Acknowledge a task running in nag mode\n")
+	fmt.Printf("  csv compact        Merge the duplicate rows of a task in the CSV file\n")
+	fmt.Printf("  dashboard          Full screen view of all the timers\n")
+	fmt.Printf("  db status|migrate  Show or apply the schema migrations of the database in CONN\n")
+	fmt.Printf("  export [-F json|ndjson|ics|timesheet-csv] [--from YYYY-MM-DD] [--to YYYY-MM-DD]\n")
+	fmt.Printf("                     [-c <category>] [--tag <tag>]\n")
+	fmt.Printf("                     Write the finished sessions for timesheets and calendars\n")
+	fmt.Printf("  lap -c <category> [note]  Record a lap in a running task\n")
+	fmt.Printf("  migrate --from <log> --to <log>\n")
+	fmt.Printf("                     Copy the history between logs: csv:<path>, sqlite://<path>, postgres://...\n")
+	fmt.Printf("  report [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--by day|week|month] [-c <category>]\n")
+	fmt.Printf("                     [--tag <tag>]\n")
+	fmt.Printf("                     Time logged by category and period, with a bar chart\n")
+	fmt.Printf("  status [-f <tmpl>] Print the running timers, e.g. -f '{{.Category}} {{.Remaining}}'\n")
+	fmt.Printf("  sync [-s <conn>] [--spool <path>]\n")
+	fmt.Printf("                     Insert the entries spooled while the database was unreachable\n")
 	fmt.Println()
 }
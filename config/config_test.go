@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"slices"
 	"testing"
 )
 
@@ -95,3 +96,64 @@ func TestParseArgs(t *testing.T) {
 		t.Fatalf("Headless expected: %v, received %v", true, parsedArgs.Headless)
 	}
 }
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantRest []string
+		wantTags []string
+		wantErr  bool
+	}{
+		{"Repeated", []string{"-t", "25m", "--tag", "client-acme", "-c", "Work", "-T", "bugfix,billable", "--tag", "bugfix"},
+			[]string{"-t", "25m", "-c", "Work"}, []string{"client-acme", "bugfix", "billable"}, false},
+		{"Equals", []string{"--tag=client-acme", "-T=bugfix", "-c", "Work"},
+			[]string{"-c", "Work"}, []string{"client-acme", "bugfix"}, false},
+		{"Value of an option", []string{"-l", "-T", "review", "-c", "Work"},
+			[]string{"-l", "-T", "review", "-c", "Work"}, nil, false},
+		{"Value named like the option", []string{"-c", "--tag", "-T", "review"},
+			[]string{"-c", "--tag"}, []string{"review"}, false},
+		{"After the separator", []string{"-T", "review", "--", "-T", "bugfix"},
+			[]string{"--", "-T", "bugfix"}, []string{"review"}, false},
+		{"Boolean before", []string{"-d", "-T", "review"}, []string{"-d"}, []string{"review"}, false},
+		{"No value", []string{"--tag", "-c", "Work"}, nil, nil, true},
+		{"Empty equals", []string{"--tag=", "-c", "Work"}, nil, nil, true},
+		{"Last", []string{"-c", "Work", "-T"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, tags, err := ExtractTags(tt.args, &ArgsCli{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(rest, tt.wantRest) {
+				t.Errorf("Arguments expected: %v, received %v", tt.wantRest, rest)
+			}
+			if !slices.Equal(tags, tt.wantTags) {
+				t.Errorf("Tags expected: %v, received %v", tt.wantTags, tags)
+			}
+		})
+	}
+}
+
+func TestParseArgsUnlimitedConfig(t *testing.T) {
+	cfg := map[string]string{"WARN": "5m", "NAG": "2m"}
+
+	osArgs := os.Args
+	defer func() { os.Args = osArgs }()
+	os.Args = []string{"jn", "-u", "-c", "Work"}
+
+	parsedArgs, err := ParseArgs(cfg)
+	if err != nil {
+		t.Fatalf("Error parsing arguments: %s", err)
+	}
+
+	if parsedArgs.Warn != "" || parsedArgs.Nag != "" {
+		t.Fatalf("Warnings and nag expected: none, received %s and %s", parsedArgs.Warn, parsedArgs.Nag)
+	}
+
+	if err := ValidateArgs(parsedArgs, cfg); err != nil {
+		t.Fatalf("Unlimited timer rejected with the configured defaults: %s", err)
+	}
+}
@@ -3,10 +3,34 @@ package database
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
-	"io"
+	"strings"
+	"syscall"
+)
+
+var csvHeaders = []string{"init_time_ms", "end_time_ms", "category", "description", "warnings", "overtime_ms", "notify_failed", "laps", "tags",
+	"task_id", "planned_end_time_ms", "outcome", "hostname", "username", "unlimited"}
+
+// Column positions in a CSV record
+const (
+	colInitTime = iota
+	colEndTime
+	colCategory
+	colDescription
+	colWarnings
+	colOvertime
+	colNotifyFailed
+	colLaps
+	colTags
+	colID
+	colPlannedEndTime
+	colOutcome
+	colHostname
+	colUser
+	colUnlimited
 )
 
 type CSVWriter interface {
@@ -32,7 +56,54 @@ func NewCSV(path string) (*CSV, error) {
 	}, nil
 }
 
+// Write logs the entry like the upsert of the SQL backends: a new task is
+// appended, a task already in the file has its row replaced.
 func (c *CSV) Write(entry *LogEntry) error {
+	record, err := entryToRecord(entry)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := c.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := c.findRecord(entry)
+	if err != nil {
+		return fmt.Errorf("looking up entry: %w", err)
+	}
+
+	if existing == nil {
+		return c.append(record)
+	}
+
+	key := entryKey(entry)
+	replaced := false
+	err = c.rewrite(func(current []string) ([]string, error) {
+		currentKey, err := recordKey(current)
+		if err != nil || currentKey != key {
+			return current, err
+		}
+		// Older versions appended a row per update, they are merged
+		if replaced {
+			return nil, nil
+		}
+		replaced = true
+		return record, nil
+	})
+	if err != nil {
+		return fmt.Errorf("updating entry: %w", err)
+	}
+
+	return nil
+}
+
+// append adds a record at the end of the file, creating it with the headers.
+// The caller holds the exclusive lock, so no other process can write the
+// headers or a record meanwhile.
+func (c *CSV) append(record []string) error {
 	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
 	file, err := os.OpenFile(c.path, flags, 0644)
 	if err != nil {
@@ -45,19 +116,11 @@ func (c *CSV) Write(entry *LogEntry) error {
 
 	// Write headers if new file
 	if stat, err := file.Stat(); err == nil && stat.Size() == 0 {
-		headers := []string{"init_time_ms", "end_time_ms", "category", "description"}
-		if err := writer.Write(headers); err != nil {
+		if err := writer.Write(csvHeaders); err != nil {
 			return fmt.Errorf("writing CSV headers: %w", err)
 		}
 	}
 
-	record := []string{
-		strconv.FormatInt(entry.InitTime, 10),
-		strconv.FormatInt(entry.EndTime, 10),
-		entry.Category,
-		entry.Description,
-	}
-	
 	if err := writer.Write(record); err != nil {
 		return fmt.Errorf("writing CSV record: %w", err)
 	}
@@ -65,6 +128,12 @@ func (c *CSV) Write(entry *LogEntry) error {
 }
 
 func (c *CSV) Exists(entry *LogEntry) (bool, error) {
+	unlock, err := c.lock(syscall.LOCK_SH)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
 	record, err := c.findRecord(entry)
 	if err != nil {
 		return false, fmt.Errorf("checking existence: %w", err)
@@ -74,6 +143,12 @@ func (c *CSV) Exists(entry *LogEntry) (bool, error) {
 }
 
 func (c *CSV) IsFinished(entry *LogEntry) (bool, error) {
+	unlock, err := c.lock(syscall.LOCK_SH)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
 	record, err := c.findRecord(entry)
 	if err != nil {
 		return false, fmt.Errorf("checking finished status: %w", err)
@@ -83,7 +158,7 @@ func (c *CSV) IsFinished(entry *LogEntry) (bool, error) {
 		return false, nil
 	}
 
-	endTime, err := strconv.ParseInt(record[1], 10, 64)
+	endTime, err := strconv.ParseInt(record[colEndTime], 10, 64)
 	if err != nil {
 		return false, fmt.Errorf("parsing end time: %w", err)
 	}
@@ -91,54 +166,427 @@ func (c *CSV) IsFinished(entry *LogEntry) (bool, error) {
 	return endTime > 0, nil
 }
 
+// TotalTime returns the millis spent in finished tasks of a category started
+// since the given epoch millis.
+func (c *CSV) TotalTime(category string, since int64) (int64, error) {
+	unlock, err := c.lock(syscall.LOCK_SH)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var total int64
+
+	err = c.eachRecord(func(record []string) (bool, error) {
+		if len(record) <= colCategory {
+			return false, fmt.Errorf("parsing record: %d columns, want at least %d", len(record), colCategory+1)
+		}
+		if record[colCategory] != category {
+			return true, nil
+		}
+
+		initTime, err := strconv.ParseInt(record[colInitTime], 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("parsing init time: %w", err)
+		}
+
+		endTime, err := strconv.ParseInt(record[colEndTime], 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("parsing end time: %w", err)
+		}
+
+		if initTime >= since && endTime > initTime {
+			total += endTime - initTime
+		}
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("computing total time: %w", err)
+	}
+
+	return total, nil
+}
+
+// Query returns the entries matching the filter. In files not compacted since
+// older versions, later rows of the same task replace the earlier ones, so the
+// filter applies to the last state of each.
+func (c *CSV) Query(filter Filter) ([]LogEntry, error) {
+	unlock, err := c.lock(syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []LogEntry
+	for i := range entries {
+		if filter.matches(&entries[i]) {
+			matched = append(matched, entries[i])
+		}
+	}
+
+	sortEntries(matched, filter.Order)
+
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, nil
+}
+
+// entries reads every task of the file, in the order they were started. The
+// caller holds the lock.
+func (c *CSV) entries() ([]LogEntry, error) {
+	var entries []LogEntry
+	index := make(map[string]int)
+
+	err := c.eachRecord(func(record []string) (bool, error) {
+		entry, err := recordToEntry(record)
+		if err != nil {
+			return false, err
+		}
+
+		key := entryKey(entry)
+		if i, ok := index[key]; ok {
+			entries[i] = *entry
+		} else {
+			index[key] = len(entries)
+			entries = append(entries, *entry)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Delete removes every row of the task, the file is rewritten
+func (c *CSV) Delete(entry *LogEntry) error {
+	unlock, err := c.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	key := entryKey(entry)
+
+	err = c.rewrite(func(record []string) ([]string, error) {
+		current, err := recordKey(record)
+		if err != nil || current == key {
+			return nil, err
+		}
+		return record, nil
+	})
+	if err != nil {
+		return fmt.Errorf("deleting entry: %w", err)
+	}
+
+	return nil
+}
+
+// Compact merges the rows of each task appended by older versions into one,
+// the last written, at the place of the first. It returns the number of rows
+// removed.
+func (c *CSV) Compact() (int, error) {
+	unlock, err := c.lock(syscall.LOCK_EX)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	last := make(map[string][]string)
+	rows := 0
+
+	err = c.eachRecord(func(record []string) (bool, error) {
+		key, err := recordKey(record)
+		if err != nil {
+			return false, err
+		}
+		last[key] = record
+		rows++
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("reading entries: %w", err)
+	}
+
+	if rows == len(last) {
+		return 0, nil
+	}
+
+	written := make(map[string]bool)
+	err = c.rewrite(func(record []string) ([]string, error) {
+		key, err := recordKey(record)
+		if err != nil || written[key] {
+			return nil, err
+		}
+		written[key] = true
+		return last[key], nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("compacting entries: %w", err)
+	}
+
+	return rows - len(last), nil
+}
+
 func (c *CSV) Close() error {
 	return nil
 }
 
+// lock takes an advisory lock shared with the other jn processes, LOCK_SH to
+// read and LOCK_EX to write, and returns the function releasing it. The lock
+// is held on a file next to the log, the log itself is replaced when it is
+// rewritten.
+func (c *CSV) lock(how int) (func(), error) {
+	file, err := os.OpenFile(c.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		// A log in a missing or read-only directory cannot be written either
+		if how == syscall.LOCK_SH && (os.IsNotExist(err) || os.IsPermission(err)) {
+			return func() {}, nil
+		}
+		return nil, fmt.Errorf("opening CSV lock: %w", err)
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), how)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("locking CSV file: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
 }
 
+// findRecord returns the row of a task, the last one in files where older
+// versions appended several
 func (c *CSV) findRecord(entry *LogEntry) ([]string, error) {
+	var found []string
+	key := entryKey(entry)
+
+	err := c.eachRecord(func(record []string) (bool, error) {
+		current, err := recordKey(record)
+		if err != nil {
+			return false, err
+		}
+
+		if current == key {
+			found = record
+		}
+		return true, nil
+	})
+
+	return found, err
+}
+
+// eachRecord calls fn with every record of the file, skipping the header,
+// until fn returns false or an error.
+func (c *CSV) eachRecord(fn func([]string) (bool, error)) error {
 	if _, err := os.Stat(c.path); os.IsNotExist(err) {
-		return nil, nil
+		return nil
 	}
 
 	file, err := os.OpenFile(c.path, os.O_RDONLY, 0444)
 	if err != nil {
-		return nil, fmt.Errorf("opening CSV file: %w", err)
+		return fmt.Errorf("opening CSV file: %w", err)
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// Files created by older versions have fewer columns
+	reader.FieldsPerRecord = -1
 	// Skip header row
 	if _, err := reader.Read(); err != nil {
 		if err == io.EOF {
-			return nil, nil
+			return nil
 		}
-		return nil, fmt.Errorf("reading CSV header: %w", err)
+		return fmt.Errorf("reading CSV header: %w", err)
 	}
 
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			return nil, fmt.Errorf("reading CSV record: %w", err)
+			return fmt.Errorf("reading CSV record: %w", err)
 		}
 
-		initTime, err := strconv.ParseInt(record[0], 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("parsing init time: %w", err)
+		next, err := fn(record)
+		if err != nil || !next {
+			return err
+		}
+	}
+}
+
+// rewrite replaces every record of the file with the one returned by update,
+// nil to remove it. The new content is written to a temporary file first, so
+// the log is never left half written.
+func (c *CSV) rewrite(update func([]string) ([]string, error)) error {
+	if !fileExists(c.path) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	writer := csv.NewWriter(tmp)
+	if err := writer.Write(csvHeaders); err != nil {
+		return fmt.Errorf("writing CSV headers: %w", err)
+	}
+
+	err = c.eachRecord(func(record []string) (bool, error) {
+		record, err := update(record)
+		if err != nil || record == nil {
+			return err == nil, err
+		}
+		return true, writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("writing CSV records: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing CSV records: %w", err)
+	}
+
+	if info, err := os.Stat(c.path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func entryToRecord(entry *LogEntry) ([]string, error) {
+	laps, err := encodeLaps(entry.Laps)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		strconv.FormatInt(entry.InitTime, 10),
+		strconv.FormatInt(entry.EndTime, 10),
+		entry.Category,
+		entry.Description,
+		joinList(entry.Warnings),
+		strconv.FormatInt(entry.Overtime, 10),
+		strconv.FormatBool(entry.NotifyFailed),
+		laps,
+		joinList(entry.Tags),
+		entry.ID,
+		strconv.FormatInt(entry.PlannedEndTime, 10),
+		string(entry.Outcome),
+		entry.Hostname,
+		entry.User,
+		strconv.FormatBool(entry.Unlimited),
+	}, nil
+}
+
+// recordToEntry parses a record, the columns missing in files created by
+// older versions keep their zero value.
+func recordToEntry(record []string) (*LogEntry, error) {
+	column := func(i int) string {
+		if i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	entry := &LogEntry{
+		Category:    column(colCategory),
+		Description: column(colDescription),
+		Warnings:    splitList(column(colWarnings)),
+		Tags:        splitList(column(colTags)),
+		ID:          column(colID),
+		Outcome:     Outcome(column(colOutcome)),
+		Hostname:    column(colHostname),
+		User:        column(colUser),
+		Unlimited:   column(colUnlimited) == "true",
+	}
+
+	var err error
+	if entry.InitTime, err = strconv.ParseInt(column(colInitTime), 10, 64); err != nil {
+		return nil, fmt.Errorf("parsing init time: %w", err)
+	}
+
+	if entry.EndTime, err = strconv.ParseInt(column(colEndTime), 10, 64); err != nil {
+		return nil, fmt.Errorf("parsing end time: %w", err)
+	}
+
+	if value := column(colOvertime); value != "" {
+		if entry.Overtime, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("parsing overtime: %w", err)
 		}
+	}
 
-		if initTime == entry.InitTime && record[2] == entry.Category {
-			return record, nil
+	if value := column(colPlannedEndTime); value != "" {
+		if entry.PlannedEndTime, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("parsing planned end time: %w", err)
 		}
 	}
 
-	return nil, nil
+	entry.NotifyFailed = column(colNotifyFailed) == "true"
+
+	if entry.Laps, err = decodeLaps(column(colLaps)); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
 }
 
+// entryKey identifies a task, like the unique constraint of the SQL backends
+func entryKey(entry *LogEntry) string {
+	return strconv.FormatInt(entry.InitTime, 10) + "/" + entry.Category
+}
+
+// recordKey returns the entryKey of a record without parsing the other columns
+func recordKey(record []string) (string, error) {
+	if len(record) <= colCategory {
+		return "", fmt.Errorf("parsing record: %d columns, want at least %d", len(record), colCategory+1)
+	}
+
+	initTime, err := strconv.ParseInt(record[colInitTime], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("parsing init time: %w", err)
+	}
+	return entryKey(&LogEntry{InitTime: initTime, Category: record[colCategory]}), nil
+}
+
+// joinList and splitList encode a list field in a single column
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func readRows(t *testing.T, path string) [][]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open CSV: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	return rows[1:]
+}
+
+func TestCSVUpsert(t *testing.T) {
+	csvFile := "upsert_test.csv"
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+
+	logger, err := NewCSV(csvFile)
+	if err != nil {
+		t.Fatalf("failed to create CSV logger: %v", err)
+	}
+
+	for _, entry := range []*LogEntry{
+		{InitTime: 1000, Category: "work"},
+		{InitTime: 3000, Category: "rest"},
+		{InitTime: 1000, EndTime: 2000, Category: "work", Outcome: OutcomeCompleted},
+	} {
+		if err := logger.Write(entry); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+	}
+
+	rows := readRows(t, csvFile)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	// The row keeps its place and holds the last state
+	if rows[0][colInitTime] != "1000" || rows[0][colEndTime] != "2000" || rows[0][colOutcome] != "completed" {
+		t.Errorf("got first row %v, want the finished work task", rows[0])
+	}
+
+	finished, err := logger.IsFinished(&LogEntry{InitTime: 1000, Category: "work"})
+	if err != nil || !finished {
+		t.Errorf("IsFinished() = %v, %v, want true", finished, err)
+	}
+}
+
+func TestCSVCompact(t *testing.T) {
+	csvFile := "compact_test.csv"
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+
+	// A file written by older versions, with a row per update and fewer columns
+	content := "init_time_ms,end_time_ms,category,description\n" +
+		"1000,0,work,first\n" +
+		"3000,0,rest,\n" +
+		"1000,2000,work,second\n" +
+		"3000,4000,rest,\n" +
+		"5000,0,work,\n"
+	if err := os.WriteFile(csvFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	logger, err := NewCSV(csvFile)
+	if err != nil {
+		t.Fatalf("failed to create CSV logger: %v", err)
+	}
+
+	finished, err := logger.IsFinished(&LogEntry{InitTime: 1000, Category: "work"})
+	if err != nil || !finished {
+		t.Errorf("IsFinished() before compacting = %v, %v, want true", finished, err)
+	}
+
+	removed, err := logger.Compact()
+	if err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Compact() removed %d rows, want 2", removed)
+	}
+
+	rows := readRows(t, csvFile)
+	want := [][]string{
+		{"1000", "2000", "work", "second"},
+		{"3000", "4000", "rest", ""},
+		{"5000", "0", "work", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got rows %v, want %v", rows, want)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if rows[i][j] != want[i][j] {
+				t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+				break
+			}
+		}
+	}
+
+	if removed, err := logger.Compact(); err != nil || removed != 0 {
+		t.Errorf("second Compact() = %d, %v, want nothing to remove", removed, err)
+	}
+}
+
+func TestCSVConcurrentWrites(t *testing.T) {
+	csvFile := "concurrent_writes_test.csv"
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errCh := make(chan error, writers)
+
+	// Each writer has its own handler, like separate jn processes
+	for i := range writers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			logger, err := NewCSV(csvFile)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			category := fmt.Sprintf("concurrent-%d", i)
+			for _, entry := range []*LogEntry{
+				{InitTime: 1000, Category: category},
+				{InitTime: 1000, EndTime: 2000, Category: category},
+			} {
+				if err := logger.Write(entry); err != nil {
+					errCh <- fmt.Errorf("writer %d: %w", i, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	close(errCh)
+	for err := range errCh {
+		t.Errorf("concurrent write error: %v", err)
+	}
+
+	rows := readRows(t, csvFile)
+	if len(rows) != writers {
+		t.Fatalf("got %d rows, want %d", len(rows), writers)
+	}
+	for _, row := range rows {
+		if row[colInitTime] == csvHeaders[colInitTime] || row[colEndTime] != "2000" {
+			t.Errorf("got row %v, want a finished task", row)
+		}
+	}
+}
+
+func TestCSVShortRecord(t *testing.T) {
+	csvFile := "short_test.csv"
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+
+	content := strings.Join(csvHeaders, ",") + "\n1000,2000,work\n3000\n"
+	if err := os.WriteFile(csvFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	logger, err := NewCSV(csvFile)
+	if err != nil {
+		t.Fatalf("failed to create CSV logger: %v", err)
+	}
+
+	if _, err := logger.TotalTime("work", 0); err == nil {
+		t.Error("TotalTime() with a truncated row succeeded, want an error")
+	}
+	if err := logger.Write(&LogEntry{InitTime: 5000, Category: "work"}); err == nil {
+		t.Error("Write() with a truncated row succeeded, want an error")
+	}
+	if _, err := logger.Exists(&LogEntry{InitTime: 1000, Category: "work"}); err == nil {
+		t.Error("Exists() with a truncated row succeeded, want an error")
+	}
+	if _, err := logger.Compact(); err == nil {
+		t.Error("Compact() with a truncated row succeeded, want an error")
+	}
+	if _, err := recordKey([]string{"1000", "2000"}); err == nil {
+		t.Error("recordKey() of a row without category succeeded, want an error")
+	}
+}
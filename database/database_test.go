@@ -47,6 +47,7 @@ func TestNewLogger(t *testing.T) {
 func TestCSVLogger(t *testing.T) {
 	tempFile := "test.csv"
 	defer os.Remove(tempFile)
+	defer os.Remove(tempFile + ".lock")
 
 	logger, err := NewLogger(tempFile, false)
 	if err != nil {
@@ -134,6 +135,7 @@ func TestSQLiteLogger(t *testing.T) {
 func TestConcurrentLogging(t *testing.T) {
 	tempFile := "concurrent_test.csv"
 	defer os.Remove(tempFile)
+	defer os.Remove(tempFile + ".lock")
 
 	logger, err := NewLogger(tempFile, false)
 	if err != nil {
@@ -181,3 +183,68 @@ func TestConcurrentLogging(t *testing.T) {
 		t.Errorf("concurrent logging error: %v", err)
 	}
 }
+
+func TestDelete(t *testing.T) {
+	dbFile := "delete_test.db"
+	csvFile := "delete_test.csv"
+	defer os.Remove(dbFile)
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+
+	sqlite, err := NewLogger("sqlite://"+dbFile, true)
+	if err != nil {
+		t.Fatalf("failed to create SQLite logger: %v", err)
+	}
+	defer sqlite.Close()
+
+	csv, err := NewLogger(csvFile, false)
+	if err != nil {
+		t.Fatalf("failed to create CSV logger: %v", err)
+	}
+
+	for name, logger := range map[string]Logger{"sqlite": sqlite, "csv": csv} {
+		t.Run(name, func(t *testing.T) {
+			entries := []*LogEntry{
+				{InitTime: 1000, Category: "work"},
+				{InitTime: 1000, EndTime: 2000, Category: "work"},
+				{InitTime: 1000, EndTime: 3000, Category: "rest"},
+			}
+
+			for _, entry := range entries {
+				if err := logger.Log(entry); err != nil {
+					t.Fatalf("failed to log entry: %v", err)
+				}
+			}
+
+			if err := logger.Delete(&LogEntry{InitTime: 1000, Category: "work"}); err != nil {
+				t.Fatalf("failed to delete entry: %v", err)
+			}
+
+			got, err := logger.Query(Filter{})
+			if err != nil {
+				t.Fatalf("failed to read entries: %v", err)
+			}
+
+			if len(got) != 1 || got[0].Category != "rest" {
+				t.Errorf("got %+v, want only the rest entry", got)
+			}
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	id := NewID()
+	if len(id) != 36 || id[14] != '4' {
+		t.Errorf("NewID() = %s, want a version 4 UUID", id)
+	}
+
+	if NewID() == id {
+		t.Error("NewID() returned the same id twice")
+	}
+
+	// The fallback without random bytes
+	var b [16]byte
+	if id := formatUUID(b); id != "00000000-0000-4000-8000-000000000000" {
+		t.Errorf("formatUUID() = %s, want a version 4 UUID", id)
+	}
+}
@@ -1,23 +1,77 @@
 package database
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
-	"just-notify/config"
-	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 type Logger interface {
 	Log(*LogEntry) error
 	Exists(*LogEntry) (bool, error)
 	IsFinished(*LogEntry) (bool, error)
+	TotalTime(category string, since int64) (int64, error)
+	Query(Filter) ([]LogEntry, error)
+	Delete(*LogEntry) error // Removes the task with the init time and category of the entry
 	Close() error
 }
 
 type LogEntry struct {
-	InitTime    int64
-	EndTime     int64
-	Category    string
-	Description string
+	ID             string // Random, set when the task starts, empty in entries logged by older versions
+	InitTime       int64
+	EndTime        int64
+	PlannedEndTime int64 // Deadline in epoch millis, pauses excluded, zero in unlimited mode
+	Category       string
+	Description    string
+	Warnings       []string // Labels of the pre-alerts fired before the deadline
+	Overtime       int64    // Millis between the deadline and the acknowledgement in nag mode
+	NotifyFailed   bool     // No notifier could show the completion notification
+	Laps           []Lap    // Splits recorded while the task was running
+	Tags           []string // Labels in addition to the category
+	Outcome        Outcome  // How the task ended, empty while it runs
+	Hostname       string
+	User           string
+	Unlimited      bool
+}
+
+// Outcome tells how a task ended
+type Outcome string
+
+const (
+	OutcomeCompleted Outcome = "completed" // Reached its deadline, finished with f or stopped while unlimited
+	OutcomeKilled    Outcome = "killed"    // Terminated, e.g. by jn --kill
+	OutcomeSignal    Outcome = "signal"    // Interrupted before its deadline, e.g. by Ctrl-C
+)
+
+// NewID returns a random identifier for a task, formatted as a UUID
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// Unique enough for a single user
+		binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixNano()))
+		binary.BigEndian.PutUint64(b[8:], uint64(os.Getpid()))
+	}
+	return formatUUID(b)
+}
+
+// formatUUID formats the bytes as a version 4 UUID, overwriting the bits of
+// the version and the variant
+func formatUUID(b [16]byte) string {
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Lap splits a task, the split of a lap is the time since the previous one
+type Lap struct {
+	Time    int64  `json:"time_ms"`    // Epoch millis when the lap was recorded
+	Elapsed int64  `json:"elapsed_ms"` // Active millis since the task started, pauses excluded
+	Note    string `json:"note,omitempty"`
 }
 
 func NewLogger(conn string, database bool) (Logger, error) {
@@ -28,54 +82,111 @@ func NewLogger(conn string, database bool) (Logger, error) {
 	return NewCSV(conn)
 }
 
-func Log(logger Logger, entry *LogEntry) error {
-	return logger.Log(entry)
+// Open opens the log at a location: csv:<path>, sqlite://<path> or a
+// postgres:// URL. A leading ~/ in a path is the home directory, csv: alone
+// is the default CSV file.
+func Open(location string) (Logger, error) {
+	switch {
+	case strings.HasPrefix(location, "csv:"):
+		path, err := expandHome(strings.TrimPrefix(location, "csv:"))
+		if err != nil {
+			return nil, err
+		}
+		return NewCSV(path)
+	case strings.HasPrefix(location, "sqlite://"), strings.HasPrefix(location, "postgresql://"),
+		strings.HasPrefix(location, "postgres://"):
+		return openDB(location)
+	default:
+		return nil, fmt.Errorf("unknown log location %q, expected csv:<path>, sqlite://<path> or postgres://...", location)
+	}
 }
 
-func (h *PgHandler) Log(entry *LogEntry) error {
-	if err := h.Insert(entry); err != nil {
-		log.Printf("Error inserting to database: %s", err)
-		if err := csvFallback(entry); err != nil {
-			return err
+// SameLocation reports whether two locations open the same log, e.g.
+// csv:~/.jn.csv and csv:/home/me/.jn.csv
+func SameLocation(a, b string) bool {
+	return normalizeLocation(a) == normalizeLocation(b)
+}
+
+// normalizeLocation expands the path of a csv: or sqlite:// location to an
+// absolute one, csv: alone to the default CSV file like Open. Other locations
+// are returned as they are.
+func normalizeLocation(location string) string {
+	for _, prefix := range []string{"csv:", "sqlite://"} {
+		if !strings.HasPrefix(location, prefix) {
+			continue
+		}
+
+		path, err := expandHome(strings.TrimPrefix(location, prefix))
+		if err != nil {
+			return location
+		}
+		if prefix == "csv:" {
+			csv, err := NewCSV(path)
+			if err != nil {
+				return location
+			}
+			path = csv.path
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
 		}
+		return prefix + path
 	}
 
-	return nil
+	return location
 }
 
-func (h *SqliteHandler) Log(entry *LogEntry) error {
-	if err := h.Insert(entry); err != nil {
-		log.Printf("Error inserting to database: %s", err)
-		if err := csvFallback(entry); err != nil {
-			return err
-		}
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
 	}
 
-	return nil
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[2:]), nil
 }
 
-func (l *CSV) Log(entry *LogEntry) error {
-	return l.Write(entry)
+func Log(logger Logger, entry *LogEntry) error {
+	return logger.Log(entry)
+}
+
+// Log inserts the entry, see Spooled to keep the entries the database fails
+// to insert
+func (h *PgHandler) Log(entry *LogEntry) error {
+	return h.Insert(entry)
 }
 
-func csvFallback(entry *LogEntry) error {
+func (h *SqliteHandler) Log(entry *LogEntry) error {
+	return h.Insert(entry)
+}
 
-	cfg := config.LoadConfig()
-	args, err := config.ParseArgs(cfg)
+func (l *CSV) Log(entry *LogEntry) error {
+	return l.Write(entry)
+}
 
-	if err != nil {
-		return err
+// encodeLaps and decodeLaps store the laps as JSON in a single column
+func encodeLaps(laps []Lap) (string, error) {
+	if len(laps) == 0 {
+		return "", nil
 	}
 
-	csvHandler, err := NewCSV(args.CsvPath)
-
+	content, err := json.Marshal(laps)
 	if err != nil {
-		return fmt.Errorf("Error creating csv handler: %s", err)
+		return "", fmt.Errorf("encoding laps: %w", err)
 	}
+	return string(content), nil
+}
 
-	if err = csvHandler.Write(entry); err != nil {
-		return fmt.Errorf("Error inserting data to csv: %s", err)
+func decodeLaps(value string) ([]Lap, error) {
+	if value == "" {
+		return nil, nil
 	}
 
-	return nil
+	var laps []Lap
+	if err := json.Unmarshal([]byte(value), &laps); err != nil {
+		return nil, fmt.Errorf("decoding laps: %w", err)
+	}
+	return laps, nil
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MigrateResult counts what happened to the entries of the source
+type MigrateResult struct {
+	Inserted   int        // Missing from the destination
+	Updated    int        // Unfinished in the destination, finished in the source
+	Unchanged  int        // Already in the destination
+	Conflicted int        // Different in the destination, left untouched
+	Conflicts  []LogEntry // The source entries in conflict
+}
+
+// Migrate copies the entries of a log into another one. Tasks are matched on
+// their init time and category, so running it again only copies what changed.
+func Migrate(from, to Logger) (MigrateResult, error) {
+	var result MigrateResult
+
+	entries, err := from.Query(Filter{})
+	if err != nil {
+		return result, fmt.Errorf("reading source: %w", err)
+	}
+
+	existing, err := to.Query(Filter{})
+	if err != nil {
+		return result, fmt.Errorf("reading destination: %w", err)
+	}
+
+	index := make(map[string]*LogEntry, len(existing))
+	for i := range existing {
+		index[entryKey(&existing[i])] = &existing[i]
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		current, ok := index[entryKey(entry)]
+		switch {
+		case !ok:
+			result.Inserted++
+		case current.EndTime <= 0 && entry.EndTime > 0:
+			// The destination stopped at the start of the task
+			result.Updated++
+		default:
+			same, err := sameEntry(current, entry)
+			if err != nil {
+				return result, err
+			}
+			if same {
+				result.Unchanged++
+			} else {
+				result.Conflicted++
+				result.Conflicts = append(result.Conflicts, *entry)
+			}
+			continue
+		}
+
+		if err := store(to, entry); err != nil {
+			return result, fmt.Errorf("writing entry %s: %w", entryKey(entry), err)
+		}
+	}
+
+	return result, nil
+}
+
+// store writes an entry without the spool of a Spooled database, which would
+// hide a failed migration
+func store(logger Logger, entry *LogEntry) error {
+	if db, ok := logger.(DB); ok {
+		return db.Insert(entry)
+	}
+	return logger.Log(entry)
+}
+
+// sameEntry compares two entries the way they are stored, the SQL backends
+// return the tags sorted
+func sameEntry(a, b *LogEntry) (bool, error) {
+	a, b = sortedTags(a), sortedTags(b)
+
+	recordA, err := entryToRecord(a)
+	if err != nil {
+		return false, err
+	}
+
+	recordB, err := entryToRecord(b)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Equal(recordA, recordB), nil
+}
+
+func sortedTags(entry *LogEntry) *LogEntry {
+	sorted := *entry
+	sorted.Tags = slices.Sorted(slices.Values(entry.Tags))
+	return &sorted
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	csvFile := "migrate_test.csv"
+	dbFile := "migrate_test.db"
+	defer os.Remove(csvFile)
+	defer os.Remove(csvFile + ".lock")
+	defer os.Remove(dbFile)
+
+	from, err := Open("csv:" + csvFile)
+	if err != nil {
+		t.Fatalf("failed to open CSV: %v", err)
+	}
+	defer from.Close()
+
+	to, err := Open("sqlite://" + dbFile)
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	defer to.Close()
+
+	for _, entry := range []*LogEntry{
+		{InitTime: 1000, EndTime: 2000, Category: "work", Laps: []Lap{{Time: 1500, Elapsed: 500}}},
+		{InitTime: 3000, EndTime: 4000, Category: "rest", Tags: []string{"home", "break"}},
+		{InitTime: 5000, EndTime: 6000, Category: "work", Description: "source"},
+		{InitTime: 7000, Category: "work"},
+	} {
+		if err := from.Log(entry); err != nil {
+			t.Fatalf("failed to log entry: %v", err)
+		}
+	}
+
+	for _, entry := range []*LogEntry{
+		{InitTime: 3000, Category: "rest"},                                            // Only the start
+		{InitTime: 5000, EndTime: 5500, Category: "work", Description: "destination"}, // Different
+	} {
+		if err := to.Log(entry); err != nil {
+			t.Fatalf("failed to log entry: %v", err)
+		}
+	}
+
+	result, err := Migrate(from, to)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	if result.Inserted != 2 || result.Updated != 1 || result.Unchanged != 0 || result.Conflicted != 1 {
+		t.Errorf("result = %+v, want 2 inserted, 1 updated and 1 conflicted", result)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].InitTime != 5000 {
+		t.Errorf("conflicts = %+v, want the task at 5000", result.Conflicts)
+	}
+
+	got, err := to.Query(Filter{})
+	if err != nil {
+		t.Fatalf("failed to read destination: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4: %+v", len(got), got)
+	}
+	if got[0].Category != "work" || len(got[0].Laps) != 1 {
+		t.Errorf("first entry = %+v, want the work task with its lap", got[0])
+	}
+	if got[1].EndTime != 4000 {
+		t.Errorf("rest end time = %d, want the finished 4000", got[1].EndTime)
+	}
+	if got[2].Description != "destination" {
+		t.Errorf("conflicting entry = %+v, want the destination kept", got[2])
+	}
+
+	// Running it again changes nothing
+	result, err = Migrate(from, to)
+	if err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+	if result.Inserted != 0 || result.Updated != 0 || result.Unchanged != 3 || result.Conflicted != 1 {
+		t.Errorf("second result = %+v, want 3 unchanged and 1 conflicted", result)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	logger, err := Open("csv:~/jn_open_test.csv")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if path := logger.(*CSV).path; path != filepath.Join(home, "jn_open_test.csv") {
+		t.Errorf("path = %s, want it in the home directory", path)
+	}
+
+	if _, err := Open("mysql://localhost/jn"); err == nil {
+		t.Error("expected an error for an unknown location")
+	}
+}
+
+func TestSameLocation(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"csv:~/.jn.csv", "csv:" + filepath.Join(home, ".jn.csv"), true},
+		{"sqlite://~/.jn.db", "sqlite://" + home + "/./.jn.db", true},
+		{"csv:jn.csv", "csv:" + filepath.Join(wd, "jn.csv"), true},
+		{"csv:", "csv:~/.jn.csv", true},
+		{"csv:", "csv:" + wd, false},
+		{"csv:~/.jn.csv", "sqlite://~/.jn.csv", false},
+		{"csv:~/.jn.csv", "csv:~/other.csv", false},
+		{"postgres://localhost/jn", "postgres://localhost/jn", true},
+		{"postgres://localhost/jn", "postgres://localhost/other", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameLocation(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameLocation(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
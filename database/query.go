@@ -0,0 +1,191 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter selects the entries returned by Query, the zero value matches every
+// entry ordered by start time.
+type Filter struct {
+	From        int64    // Started at or after, epoch millis, zero for no lower bound
+	To          int64    // Started before, epoch millis, zero for no upper bound
+	Categories  []string // Any of these categories, all when empty
+	Tags        []string // Every one of these tags
+	State       State    // Finished, unfinished or both
+	Description string   // Substring of the description, case insensitive
+	Order       Order    // By start time, then by category
+	Limit       int      // Maximum number of entries, zero for no limit
+}
+
+// State selects entries by their end time
+type State int
+
+const (
+	AnyState   State = iota
+	Finished         // Entries with an end time
+	Unfinished       // Entries still running or interrupted before logging the end
+)
+
+type Order int
+
+const (
+	Ascending Order = iota
+	Descending
+)
+
+// matches reports whether an entry passes the filter, ignoring order and limit
+func (f Filter) matches(entry *LogEntry) bool {
+	if f.From != 0 && entry.InitTime < f.From {
+		return false
+	}
+
+	if f.To != 0 && entry.InitTime >= f.To {
+		return false
+	}
+
+	if len(f.Categories) > 0 {
+		found := false
+		for _, category := range f.Categories {
+			if entry.Category == category {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, tag := range f.Tags {
+		if !slices.Contains(entry.Tags, tag) {
+			return false
+		}
+	}
+
+	switch f.State {
+	case Finished:
+		if entry.EndTime <= 0 {
+			return false
+		}
+	case Unfinished:
+		if entry.EndTime > 0 {
+			return false
+		}
+	}
+
+	if f.Description != "" && !strings.Contains(strings.ToLower(entry.Description), strings.ToLower(f.Description)) {
+		return false
+	}
+
+	return true
+}
+
+// sortEntries orders the entries like the ORDER BY of the SQL backends
+func sortEntries(entries []LogEntry, order Order) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if order == Descending {
+			a, b = b, a
+		}
+		if a.InitTime != b.InitTime {
+			return a.InitTime < b.InitTime
+		}
+		return a.Category < b.Category
+	})
+}
+
+// sqlDialect holds what differs between the SQL backends
+type sqlDialect struct {
+	driver      string             // Name of the database/sql driver
+	placeholder func(n int) string // Placeholder of the nth argument, from 1
+	like        string             // Case insensitive LIKE operator
+	lock        string             // Serializes the schema migrations, empty when sqliteDSN already does
+	migrations  []migration
+}
+
+var (
+	pgDialect = sqlDialect{
+		driver:      "postgres",
+		placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		like:        "ILIKE",
+		lock:        "SELECT pg_advisory_xact_lock(4861)",
+		migrations:  pgMigrations,
+	}
+	sqliteDialect = sqlDialect{
+		driver:      "sqlite3",
+		placeholder: func(int) string { return "?" },
+		like:        "LIKE",
+		migrations:  sqliteMigrations,
+	}
+)
+
+// buildQuery returns the SELECT of the entries matching the filter and its
+// arguments
+func (d sqlDialect) buildQuery(filter Filter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	arg := func(value any) string {
+		args = append(args, value)
+		return d.placeholder(len(args))
+	}
+
+	if filter.From != 0 {
+		conditions = append(conditions, "init_time_ms >= "+arg(filter.From))
+	}
+
+	if filter.To != 0 {
+		conditions = append(conditions, "init_time_ms < "+arg(filter.To))
+	}
+
+	if len(filter.Categories) > 0 {
+		placeholders := make([]string, len(filter.Categories))
+		for i, category := range filter.Categories {
+			placeholders[i] = arg(category)
+		}
+		conditions = append(conditions, "category IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM log_tags t
+			WHERE t.init_time_ms = logs.init_time_ms AND t.category = logs.category AND t.tag = %s)`, arg(tag)))
+	}
+
+	switch filter.State {
+	case Finished:
+		conditions = append(conditions, "COALESCE(end_time_ms, 0) > 0")
+	case Unfinished:
+		conditions = append(conditions, "COALESCE(end_time_ms, 0) <= 0")
+	}
+
+	if filter.Description != "" {
+		pattern := "%" + escapeLike(filter.Description) + "%"
+		conditions = append(conditions, fmt.Sprintf(`COALESCE(description, '') %s %s ESCAPE '\'`, d.like, arg(pattern)))
+	}
+
+	query := selectEntries
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	direction := "ASC"
+	if filter.Order == Descending {
+		direction = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY init_time_ms %s, category %s", direction, direction)
+
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	return query, args
+}
+
+// escapeLike makes the wildcards of a LIKE pattern literal
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
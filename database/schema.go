@@ -0,0 +1,262 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration changes the schema of a SQL log. Versions start at 1, follow each
+// other and are never changed once released: a new column is a new migration.
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+// The first migrations rebuild the schema created before the versions were
+// recorded, they are safe to run on a table which already has their columns.
+var pgMigrations = []migration{
+	{1, "create the logs table", execStatement(`
+	CREATE TABLE IF NOT EXISTS logs (
+		id SERIAL PRIMARY KEY,
+		init_time_ms BIGINT NOT NULL,
+		end_time_ms BIGINT,
+		category TEXT NOT NULL,
+		description TEXT,
+	    constraint unique_task unique (init_time_ms, category)
+	)`)},
+	{2, "add the warnings", execStatement(`ALTER TABLE logs ADD COLUMN IF NOT EXISTS warnings TEXT`)},
+	{3, "add the overtime", execStatement(`ALTER TABLE logs ADD COLUMN IF NOT EXISTS overtime_ms BIGINT NOT NULL DEFAULT 0`)},
+	{4, "add the notification failures", execStatement(`ALTER TABLE logs ADD COLUMN IF NOT EXISTS notify_failed BOOLEAN NOT NULL DEFAULT false`)},
+	{5, "add the laps", execStatement(`ALTER TABLE logs ADD COLUMN IF NOT EXISTS laps TEXT`)},
+	{6, "add the tags", execStatement(`
+	CREATE TABLE IF NOT EXISTS log_tags (
+		init_time_ms BIGINT NOT NULL,
+		category TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (init_time_ms, category, tag)
+	);
+	CREATE INDEX IF NOT EXISTS log_tags_tag ON log_tags(tag);`)},
+	{7, "add the task id, planned end, outcome, host, user and unlimited flag", execStatement(`
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS task_id TEXT;
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS planned_end_time_ms BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS outcome TEXT;
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS hostname TEXT;
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS username TEXT;
+	ALTER TABLE logs ADD COLUMN IF NOT EXISTS unlimited BOOLEAN NOT NULL DEFAULT false;
+	CREATE UNIQUE INDEX IF NOT EXISTS logs_task_id ON logs(task_id);`)},
+}
+
+var sqliteMigrations = []migration{
+	{1, "create the logs table", execStatement(`
+	CREATE TABLE IF NOT EXISTS logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		init_time_ms BIGINT NOT NULL,
+		end_time_ms BIGINT,
+		category TEXT NOT NULL,
+		description TEXT
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS unique_task ON logs(init_time_ms, category);`)},
+	{2, "add the warnings", addSqliteColumn("warnings", "TEXT")},
+	{3, "add the overtime", addSqliteColumn("overtime_ms", "BIGINT NOT NULL DEFAULT 0")},
+	{4, "add the notification failures", addSqliteColumn("notify_failed", "BOOLEAN NOT NULL DEFAULT false")},
+	{5, "add the laps", addSqliteColumn("laps", "TEXT")},
+	{6, "add the tags", execStatement(`
+	CREATE TABLE IF NOT EXISTS log_tags (
+		init_time_ms BIGINT NOT NULL,
+		category TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (init_time_ms, category, tag)
+	);
+	CREATE INDEX IF NOT EXISTS log_tags_tag ON log_tags(tag);`)},
+	{7, "add the task id, planned end, outcome, host, user and unlimited flag", func(tx *sql.Tx) error {
+		for _, column := range [][2]string{
+			{"task_id", "TEXT"},
+			{"planned_end_time_ms", "BIGINT NOT NULL DEFAULT 0"},
+			{"outcome", "TEXT"},
+			{"hostname", "TEXT"},
+			{"username", "TEXT"},
+			{"unlimited", "BOOLEAN NOT NULL DEFAULT false"},
+		} {
+			if err := addSqliteColumn(column[0], column[1])(tx); err != nil {
+				return err
+			}
+		}
+		return execStatement(`CREATE UNIQUE INDEX IF NOT EXISTS logs_task_id ON logs(task_id)`)(tx)
+	}},
+}
+
+func execStatement(statement string) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(statement)
+		return err
+	}
+}
+
+// addSqliteColumn adds a column unless it exists, SQLite has no ADD COLUMN IF
+// NOT EXISTS
+func addSqliteColumn(name, definition string) func(*sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM pragma_table_info('logs') WHERE name = ?)`, name).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("checking column %s: %w", name, err)
+		}
+
+		if exists {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE logs ADD COLUMN %s %s", name, definition))
+		return err
+	}
+}
+
+// Schema manages the versions of the schema of a SQL log
+type Schema struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// MigrationStatus describes a migration of the schema
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   int64 // Epoch millis, zero while pending
+}
+
+// OpenSchema connects to a database without applying the pending migrations,
+// unlike NewLogger
+func OpenSchema(dsn string) (*Schema, error) {
+	conn, dialect, err := openSQL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{db: conn, dialect: dialect}, nil
+}
+
+func (s *Schema) Close() error {
+	return s.db.Close()
+}
+
+// Version returns the last migration applied, zero for an empty database
+func (s *Schema) Version() (int, error) {
+	if err := s.createTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Latest returns the version the migrations lead to
+func (s *Schema) Latest() int {
+	return s.dialect.migrations[len(s.dialect.migrations)-1].version
+}
+
+// Status lists every migration with the time it was applied
+func (s *Schema) Status() ([]MigrationStatus, error) {
+	if err := s.createTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT version, applied_at_ms FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("reading migration: %w", err)
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(s.dialect.migrations))
+	for _, m := range s.dialect.migrations {
+		statuses = append(statuses, MigrationStatus{Version: m.version, Description: m.description, AppliedAt: applied[m.version]})
+	}
+	return statuses, nil
+}
+
+// Migrate applies the pending migrations in order, each in its own
+// transaction, and returns the ones it applied
+func (s *Schema) Migrate() ([]MigrationStatus, error) {
+	if err := s.createTable(); err != nil {
+		return nil, err
+	}
+
+	var applied []MigrationStatus
+	for _, m := range s.dialect.migrations {
+		at, err := s.apply(m)
+		if err != nil {
+			return applied, fmt.Errorf("migrating schema to version %d (%s): %w", m.version, m.description, err)
+		}
+		if at != 0 {
+			applied = append(applied, MigrationStatus{Version: m.version, Description: m.description, AppliedAt: at})
+		}
+	}
+	return applied, nil
+}
+
+// apply runs a migration unless another process already did, it returns the
+// time it was applied or zero
+func (s *Schema) apply(m migration) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lock != "" {
+		if _, err := tx.Exec(s.dialect.lock); err != nil {
+			return 0, err
+		}
+	}
+
+	var done bool
+	query := `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ` + s.dialect.placeholder(1) + `)`
+	if err := tx.QueryRow(query, m.version).Scan(&done); err != nil {
+		return 0, err
+	}
+	if done {
+		return 0, nil
+	}
+
+	if err := m.apply(tx); err != nil {
+		return 0, err
+	}
+
+	at := time.Now().UnixMilli()
+	insert := fmt.Sprintf(`INSERT INTO schema_migrations (version, description, applied_at_ms) VALUES (%s, %s, %s)`,
+		s.dialect.placeholder(1), s.dialect.placeholder(2), s.dialect.placeholder(3))
+	if _, err := tx.Exec(insert, m.version, m.description, at); err != nil {
+		return 0, err
+	}
+
+	return at, tx.Commit()
+}
+
+func (s *Schema) createTable() error {
+	_, err := s.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at_ms BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestSchemaMigrate(t *testing.T) {
+	dbFile := "schema_test.db"
+	defer os.Remove(dbFile)
+
+	schema, err := OpenSchema("sqlite://" + dbFile)
+	if err != nil {
+		t.Fatalf("OpenSchema() error = %v", err)
+	}
+	defer schema.Close()
+
+	statuses, err := schema.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(statuses) != schema.Latest() {
+		t.Fatalf("got %d migrations, want %d", len(statuses), schema.Latest())
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != 0 {
+			t.Errorf("migration %d applied before Migrate", status.Version)
+		}
+	}
+
+	applied, err := schema.Migrate()
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(applied) != schema.Latest() {
+		t.Errorf("applied %d migrations, want %d", len(applied), schema.Latest())
+	}
+
+	if version, err := schema.Version(); err != nil || version != schema.Latest() {
+		t.Errorf("Version() = %d, %v, want %d", version, err, schema.Latest())
+	}
+
+	applied, err = schema.Migrate()
+	if err != nil || len(applied) != 0 {
+		t.Errorf("second Migrate() = %+v, %v, want nothing applied", applied, err)
+	}
+}
+
+func TestSchemaUpgrade(t *testing.T) {
+	dbFile := "schema_upgrade_test.db"
+	defer os.Remove(dbFile)
+
+	// A database created before the versions were recorded, with some of the
+	// columns added since
+	db, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		init_time_ms BIGINT NOT NULL,
+		end_time_ms BIGINT,
+		category TEXT NOT NULL,
+		description TEXT,
+		warnings TEXT
+	);
+	CREATE UNIQUE INDEX unique_task ON logs(init_time_ms, category);
+	INSERT INTO logs (init_time_ms, end_time_ms, category, warnings) VALUES (1000, 2000, 'work', '5m');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to create the old schema: %v", err)
+	}
+
+	logger, err := NewLogger("sqlite://"+dbFile, true)
+	if err != nil {
+		t.Fatalf("failed to open the old database: %v", err)
+	}
+	defer logger.Close()
+
+	if err := logger.Log(&LogEntry{InitTime: 3000, EndTime: 4000, Category: "work", Laps: []Lap{{Time: 3500}}}); err != nil {
+		t.Fatalf("failed to log entry: %v", err)
+	}
+
+	got, err := logger.Query(Filter{})
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(got) != 2 || len(got[0].Warnings) != 1 || len(got[1].Laps) != 1 {
+		t.Errorf("got %+v, want the old entry with its warning and the new one with its lap", got)
+	}
+
+	schema, err := OpenSchema("sqlite://" + dbFile)
+	if err != nil {
+		t.Fatalf("OpenSchema() error = %v", err)
+	}
+	defer schema.Close()
+
+	if version, err := schema.Version(); err != nil || version != schema.Latest() {
+		t.Errorf("Version() = %d, %v, want %d", version, err, schema.Latest())
+	}
+}
+
+func TestSchemaConcurrentMigrate(t *testing.T) {
+	dbFile := "schema_concurrent_test.db"
+	defer os.Remove(dbFile)
+
+	// Every jn started on a new database migrates it
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db, err := openDB("sqlite://" + dbFile)
+			if err == nil {
+				db.Close()
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent openDB() error = %v", err)
+		}
+	}
+}
+
+func TestSqliteDSN(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"jn.db", "jn.db?_txlock=immediate"},
+		{"jn.db?_busy_timeout=1000", "jn.db?_busy_timeout=1000&_txlock=immediate"},
+		{"jn.db?_txlock=exclusive", "jn.db?_txlock=exclusive"},
+	}
+
+	for _, tt := range tests {
+		if got := sqliteDSN(tt.path); got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Spool keeps the entries a database failed to insert in a local CSV file,
+// until Sync inserts them
+type Spool struct {
+	csv *CSV
+}
+
+// SyncResult counts the entries of a Sync
+type SyncResult struct {
+	Synced int // Inserted in the database
+	Stale  int // Unfinished while the database has the task finished, dropped
+}
+
+// NewSpool opens the spool at path, ~/.jn.spool.csv when empty. The file is
+// created by the first entry spooled.
+func NewSpool(path string) (*Spool, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".jn.spool.csv")
+	}
+
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spool{csv: &CSV{path: path}}, nil
+}
+
+func (s *Spool) Path() string {
+	return s.csv.path
+}
+
+// Write adds an entry, replacing the one of the same task
+func (s *Spool) Write(entry *LogEntry) error {
+	return s.csv.Write(entry)
+}
+
+// Sync inserts the spooled entries in the database, in the order they were
+// started, and removes them from the spool. It stops at the first failure,
+// the remaining entries wait for the next Sync. The spool stays locked
+// meanwhile, so an entry spooled by another process is never lost.
+func (s *Spool) Sync(db DB) (SyncResult, error) {
+	var result SyncResult
+
+	unlock, err := s.csv.lock(syscall.LOCK_EX)
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	entries, err := s.csv.entries()
+	if err != nil {
+		return result, err
+	}
+
+	done := make(map[string]bool)
+	var syncErr error
+	for i := range entries {
+		entry := &entries[i]
+
+		// The start of a task spooled while its end reached the database
+		if entry.EndTime <= 0 {
+			finished, err := db.IsFinished(entry)
+			if err != nil {
+				syncErr = fmt.Errorf("checking entry %s: %w", entryKey(entry), err)
+				break
+			}
+			if finished {
+				done[entryKey(entry)] = true
+				result.Stale++
+				continue
+			}
+		}
+
+		if err := db.Insert(entry); err != nil {
+			syncErr = fmt.Errorf("inserting entry %s: %w", entryKey(entry), err)
+			break
+		}
+		done[entryKey(entry)] = true
+		result.Synced++
+	}
+
+	if len(done) > 0 {
+		err := s.csv.rewrite(func(record []string) ([]string, error) {
+			key, err := recordKey(record)
+			if err != nil || done[key] {
+				return nil, err
+			}
+			return record, nil
+		})
+		// The entries are inserted again by the next Sync, which is harmless
+		if err != nil {
+			return result, fmt.Errorf("updating spool: %w", err)
+		}
+	}
+
+	return result, syncErr
+}
+
+// Spooled logs to a database, the entries it fails to insert are written to
+// the spool instead. Offline, when the database could not be opened, every
+// entry is spooled until Sync reaches the database.
+type Spooled struct {
+	db    DB // nil when offline
+	conn  string
+	spool *Spool
+}
+
+// NewSpooled opens the database at conn with the spool as the fallback. A
+// database which cannot be opened or migrated leaves it offline, see Offline.
+func NewSpooled(conn string, spool *Spool) *Spooled {
+	s := &Spooled{conn: conn, spool: spool}
+
+	db, err := openDB(conn)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		log.Printf("Error opening database, logging to %s: %s", spool.Path(), err)
+		return s
+	}
+
+	s.db = db
+	return s
+}
+
+// Offline reports whether the entries go to the spool only
+func (s *Spooled) Offline() bool {
+	return s.db == nil
+}
+
+// goOffline closes the database after a failure, so the start and the end of
+// a task are spooled together
+func (s *Spooled) goOffline(err error) {
+	log.Printf("Error reading database, logging to %s: %s", s.spool.Path(), err)
+	s.db.Close()
+	s.db = nil
+}
+
+func (s *Spooled) Log(entry *LogEntry) error {
+	if s.Offline() {
+		return s.spool.Write(entry)
+	}
+
+	if err := s.db.Insert(entry); err != nil {
+		log.Printf("Error inserting to database, spooled to %s: %s", s.spool.Path(), err)
+		if err := s.spool.Write(entry); err != nil {
+			return fmt.Errorf("spooling entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Spooled) Exists(entry *LogEntry) (bool, error) {
+	if !s.Offline() {
+		exists, err := s.db.Exists(entry)
+		if err == nil {
+			return exists, nil
+		}
+		s.goOffline(err)
+	}
+
+	return s.spool.csv.Exists(entry)
+}
+
+func (s *Spooled) IsFinished(entry *LogEntry) (bool, error) {
+	if s.Offline() {
+		return s.spool.csv.IsFinished(entry)
+	}
+	return s.db.IsFinished(entry)
+}
+
+// TotalTime only counts the spooled entries when offline
+func (s *Spooled) TotalTime(category string, since int64) (int64, error) {
+	if s.Offline() {
+		return s.spool.csv.TotalTime(category, since)
+	}
+	return s.db.TotalTime(category, since)
+}
+
+func (s *Spooled) Query(filter Filter) ([]LogEntry, error) {
+	if s.Offline() {
+		return s.spool.csv.Query(filter)
+	}
+	return s.db.Query(filter)
+}
+
+// Delete removes the task from the database and from the spool, which holds
+// its start when the database failed to insert it
+func (s *Spooled) Delete(entry *LogEntry) error {
+	if !s.Offline() {
+		if err := s.db.Delete(entry); err != nil {
+			return err
+		}
+	}
+	return s.spool.csv.Delete(entry)
+}
+
+func (s *Spooled) Close() error {
+	if s.Offline() {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Sync inserts the entries of the spool, see Spool.Sync. Offline, it opens
+// the database first, which applies its migrations.
+func (s *Spooled) Sync() (SyncResult, error) {
+	if s.Offline() {
+		db, err := openDB(s.conn)
+		if err != nil {
+			if db != nil {
+				db.Close()
+			}
+			return SyncResult{}, fmt.Errorf("opening database: %w", err)
+		}
+		s.db = db
+	}
+
+	return s.spool.Sync(s.db)
+}
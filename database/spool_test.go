@@ -0,0 +1,213 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	dbFile := "spool_test.db"
+	spoolFile := "spool_test.csv"
+	defer os.Remove(dbFile)
+	defer os.Remove(spoolFile)
+	defer os.Remove(spoolFile + ".lock")
+
+	spool, err := NewSpool(spoolFile)
+	if err != nil {
+		t.Fatalf("failed to create spool: %v", err)
+	}
+
+	db, err := openDB("sqlite://" + dbFile)
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	// The start of this task reached the database, its end is spooled
+	if err := db.Insert(&LogEntry{InitTime: 1000, Category: "work"}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+	// The start of this one is spooled, its end reached the database
+	if err := db.Insert(&LogEntry{InitTime: 3000, EndTime: 4000, Category: "rest"}); err != nil {
+		t.Fatalf("failed to insert entry: %v", err)
+	}
+
+	// A database which fails every insert
+	offline := NewSpooled("sqlite://"+dbFile, spool)
+	if offline.Offline() {
+		t.Fatal("NewSpooled() is offline, want the SQLite database open")
+	}
+	offline.db.Close()
+
+	for _, entry := range []*LogEntry{
+		{InitTime: 1000, EndTime: 2000, Category: "work", Outcome: OutcomeCompleted},
+		{InitTime: 3000, Category: "rest"},
+		{InitTime: 5000, Category: "work", Tags: []string{"offline"}},
+		{InitTime: 5000, EndTime: 6000, Category: "work", Tags: []string{"offline"}},
+	} {
+		if err := offline.Log(entry); err != nil {
+			t.Fatalf("failed to spool entry: %v", err)
+		}
+	}
+
+	if _, err := offline.Sync(); err == nil {
+		t.Error("Sync() with the database closed succeeded, want an error")
+	}
+
+	result, err := spool.Sync(db)
+	if err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if result.Synced != 2 || result.Stale != 1 {
+		t.Errorf("Sync() = %+v, want 2 synced and 1 stale", result)
+	}
+
+	got, err := db.Query(Filter{})
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3", len(got))
+	}
+	for _, entry := range got {
+		if entry.EndTime <= 0 {
+			t.Errorf("entry %s is unfinished, want every entry finished", entryKey(&entry))
+		}
+	}
+	if got[2].InitTime != 5000 || len(got[2].Tags) != 1 {
+		t.Errorf("got %+v, want the entry spooled with its tags", got[2])
+	}
+
+	left, err := spool.csv.Query(Filter{})
+	if err != nil || len(left) != 0 {
+		t.Errorf("spool holds %v, %v after the sync, want nothing", left, err)
+	}
+
+	result, err = spool.Sync(db)
+	if err != nil || result.Synced != 0 || result.Stale != 0 {
+		t.Errorf("second Sync() = %+v, %v, want nothing to sync", result, err)
+	}
+}
+
+func TestSpooledOffline(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(filepath.Join(dir, "spool.csv"))
+	if err != nil {
+		t.Fatalf("failed to create spool: %v", err)
+	}
+
+	// Nothing listens on port 1
+	offline := NewSpooled("postgres://jn@127.0.0.1:1/jn?sslmode=disable&connect_timeout=1", spool)
+	if !offline.Offline() {
+		t.Fatal("NewSpooled() with an unreachable database is online, want offline")
+	}
+
+	start := &LogEntry{InitTime: 1000, Category: "work"}
+	if exists, err := offline.Exists(start); err != nil || exists {
+		t.Fatalf("Exists() = %v, %v, want false", exists, err)
+	}
+	if err := offline.Log(start); err != nil {
+		t.Fatalf("failed to log the start: %v", err)
+	}
+	if exists, err := offline.Exists(start); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v after the start, want true", exists, err)
+	}
+	if err := offline.Log(&LogEntry{InitTime: 1000, EndTime: 2000, Category: "work"}); err != nil {
+		t.Fatalf("failed to log the end: %v", err)
+	}
+
+	got, err := spool.csv.Query(Filter{})
+	if err != nil || len(got) != 1 || got[0].EndTime != 2000 {
+		t.Fatalf("spool holds %+v, %v, want the finished entry", got, err)
+	}
+
+	if _, err := offline.Sync(); err == nil {
+		t.Error("Sync() with the database unreachable succeeded, want an error")
+	}
+	if err := offline.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestSpooledOfflineSync(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(filepath.Join(dir, "spool.csv"))
+	if err != nil {
+		t.Fatalf("failed to create spool: %v", err)
+	}
+
+	// The directory of the database is missing, e.g. an unmounted share
+	dbDir := filepath.Join(dir, "share")
+	conn := "sqlite://" + filepath.Join(dbDir, "jn.db")
+	offline := NewSpooled(conn, spool)
+	if !offline.Offline() {
+		t.Fatal("NewSpooled() with a missing directory is online, want offline")
+	}
+	defer offline.Close()
+
+	if err := offline.Log(&LogEntry{InitTime: 1000, EndTime: 2000, Category: "work"}); err != nil {
+		t.Fatalf("failed to log entry: %v", err)
+	}
+
+	if err := os.Mkdir(dbDir, 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	result, err := offline.Sync()
+	if err != nil || result.Synced != 1 {
+		t.Fatalf("Sync() = %+v, %v, want the entry synced", result, err)
+	}
+	if offline.Offline() {
+		t.Error("Offline() after Sync() = true, want the database open")
+	}
+
+	schema, err := OpenSchema(conn)
+	if err != nil {
+		t.Fatalf("OpenSchema() error = %v", err)
+	}
+	defer schema.Close()
+	if version, err := schema.Version(); err != nil || version != schema.Latest() {
+		t.Errorf("Version() = %d, %v, want the migrations applied", version, err)
+	}
+
+	// A database failing to check a task goes offline for the task
+	offline.db.Close()
+	if exists, err := offline.Exists(&LogEntry{InitTime: 3000, Category: "work"}); err != nil || exists {
+		t.Errorf("Exists() = %v, %v with the database closed, want false from the spool", exists, err)
+	}
+	if !offline.Offline() {
+		t.Error("Offline() after a failed Exists() = false, want true")
+	}
+}
+
+func TestSpooledDelete(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(filepath.Join(dir, "spool.csv"))
+	if err != nil {
+		t.Fatalf("failed to create spool: %v", err)
+	}
+
+	online := NewSpooled("sqlite://"+filepath.Join(dir, "jn.db"), spool)
+	if online.Offline() {
+		t.Fatal("NewSpooled() is offline, want the SQLite database open")
+	}
+	defer online.Close()
+
+	// The start spooled after a failed insert, then the task cancelled
+	entry := &LogEntry{InitTime: 1000, Category: "work"}
+	if err := spool.Write(entry); err != nil {
+		t.Fatalf("failed to spool entry: %v", err)
+	}
+	if err := online.Delete(entry); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	result, err := online.Sync()
+	if err != nil || result.Synced != 0 {
+		t.Errorf("Sync() = %+v, %v, want the cancelled task gone", result, err)
+	}
+	if got, err := online.Query(Filter{}); err != nil || len(got) != 0 {
+		t.Errorf("database holds %+v, %v, want nothing", got, err)
+	}
+}
@@ -14,8 +14,8 @@ type dbHandler struct {
 
 type DB interface {
 	Insert(*LogEntry) error
-	Exists(*LogEntry)(bool, error) 
-	IsFinished(*LogEntry)(bool, error) 
+	Exists(*LogEntry) (bool, error)
+	IsFinished(*LogEntry) (bool, error)
 	initSchema() error
 	Close() error
 	Logger
@@ -34,61 +34,233 @@ type SqliteHandler struct {
 }
 
 func openDB(dsn string) (DB, error) {
-	var handler, connStr string
+	conn, dialect, err := openSQL(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var db DB
+	switch dialect.driver {
+	case "sqlite3":
+		db = &SqliteHandler{dbHandler: dbHandler{db: conn}}
+	case "postgres":
+		db = &PgHandler{dbHandler: dbHandler{db: conn}}
+	}
+
+	return db, db.initSchema()
+}
+
+func openSQL(dsn string) (*sql.DB, sqlDialect, error) {
+	var dialect sqlDialect
+	var connStr string
 
 	switch {
 	case strings.HasPrefix(dsn, "sqlite://"):
-		handler = "sqlite3"
-		connStr = strings.TrimPrefix(dsn, "sqlite://")
+		dialect = sqliteDialect
+		path, err := expandHome(strings.TrimPrefix(dsn, "sqlite://"))
+		if err != nil {
+			return nil, dialect, err
+		}
+		connStr = sqliteDSN(path)
 	case strings.HasPrefix(dsn, "postgresql://") || strings.HasPrefix(dsn, "postgres://"):
-		handler = "postgres"
+		dialect = pgDialect
 		connStr = dsn
 	default:
-		return nil, fmt.Errorf("unsupported driver in DSN: %s", dsn)
+		return nil, dialect, fmt.Errorf("unsupported driver in DSN: %s", dsn)
+	}
+
+	conn, err := sql.Open(dialect.driver, connStr)
+	return conn, dialect, err
+}
+
+// sqliteDSN makes the transactions take the write lock when they begin, so
+// two processes migrating the schema wait for each other instead of failing
+// with SQLITE_BUSY when the reader of the version upgrades to a writer
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "_txlock=") {
+		return path
 	}
+	if strings.Contains(path, "?") {
+		return path + "&_txlock=immediate"
+	}
+	return path + "?_txlock=immediate"
+}
+
+const selectEntries = `
+	SELECT init_time_ms, COALESCE(end_time_ms, 0), category, COALESCE(description, ''),
+		COALESCE(warnings, ''), overtime_ms, notify_failed, COALESCE(laps, ''), COALESCE(task_id, ''),
+		planned_end_time_ms, COALESCE(outcome, ''), COALESCE(hostname, ''), COALESCE(username, ''), unlimited
+	FROM logs`
 
-	conn, err := sql.Open(handler, connStr)
+// query returns the entries matching the filter in the SQL dialect
+func (h *dbHandler) query(dialect sqlDialect, filter Filter) ([]LogEntry, error) {
+	query, args := dialect.buildQuery(filter)
+
+	rows, err := h.db.Query(query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("querying entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []LogEntry
+	for rows.Next() {
+		var entry LogEntry
+		var warnings, laps, outcome string
+		if err := rows.Scan(&entry.InitTime, &entry.EndTime, &entry.Category, &entry.Description,
+			&warnings, &entry.Overtime, &entry.NotifyFailed, &laps, &entry.ID, &entry.PlannedEndTime,
+			&outcome, &entry.Hostname, &entry.User, &entry.Unlimited); err != nil {
+			return nil, fmt.Errorf("reading entry: %w", err)
+		}
+		entry.Warnings = splitList(warnings)
+		entry.Outcome = Outcome(outcome)
+		if entry.Laps, err = decodeLaps(laps); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	var db DB
-	switch handler {
-	case "sqlite3":
-		db = &SqliteHandler{dbHandler: dbHandler{db: conn}}
-	case "postgres":
-		db = &PgHandler{dbHandler: dbHandler{db: conn}}
+	if err := h.loadTags(dialect, filter, entries); err != nil {
+		return nil, err
 	}
 
-	return db, db.initSchema()
+	return entries, nil
+}
+
+// loadTags fills the tags of the entries, read from the tags of the tasks
+// started in the range of the filter
+func (h *dbHandler) loadTags(dialect sqlDialect, filter Filter, entries []LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	index := make(map[string]*LogEntry, len(entries))
+	for i := range entries {
+		index[entryKey(&entries[i])] = &entries[i]
+	}
+
+	var conditions []string
+	var args []any
+	if filter.From != 0 {
+		args = append(args, filter.From)
+		conditions = append(conditions, "init_time_ms >= "+dialect.placeholder(len(args)))
+	}
+	if filter.To != 0 {
+		args = append(args, filter.To)
+		conditions = append(conditions, "init_time_ms < "+dialect.placeholder(len(args)))
+	}
+
+	query := `SELECT init_time_ms, category, tag FROM log_tags`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY tag"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("querying tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task LogEntry
+		var tag string
+		if err := rows.Scan(&task.InitTime, &task.Category, &tag); err != nil {
+			return fmt.Errorf("reading tag: %w", err)
+		}
+		if entry, ok := index[entryKey(&task)]; ok {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+// insert upserts the entry with the statement of the backend and replaces its
+// tags, in a single transaction
+func (h *dbHandler) insert(dialect sqlDialect, stmt string, data *LogEntry) error {
+	laps, err := encodeLaps(data.Laps)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(stmt, data.InitTime, data.EndTime, data.Category, data.Description,
+		joinList(data.Warnings), data.Overtime, data.NotifyFailed, laps, nullable(data.ID), data.PlannedEndTime,
+		string(data.Outcome), data.Hostname, data.User, data.Unlimited)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("init_time_ms = %s AND category = %s", dialect.placeholder(1), dialect.placeholder(2))
+	if _, err := tx.Exec(`DELETE FROM log_tags WHERE `+key, data.InitTime, data.Category); err != nil {
+		return fmt.Errorf("replacing tags: %w", err)
+	}
+
+	insertTag := fmt.Sprintf(`INSERT INTO log_tags (init_time_ms, category, tag) VALUES (%s, %s, %s)`,
+		dialect.placeholder(1), dialect.placeholder(2), dialect.placeholder(3))
+	for _, tag := range data.Tags {
+		if _, err := tx.Exec(insertTag, data.InitTime, data.Category, tag); err != nil {
+			return fmt.Errorf("adding tag %s: %w", tag, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// nullable stores an empty string as NULL, for the columns with a unique index
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func (h *dbHandler) delete(dialect sqlDialect, entry *LogEntry) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	key := fmt.Sprintf("init_time_ms = %s AND category = %s", dialect.placeholder(1), dialect.placeholder(2))
+	for _, table := range []string{"logs", "log_tags"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE `+key, entry.InitTime, entry.Category); err != nil {
+			return fmt.Errorf("deleting entry: %w", err)
+		}
+	}
+
+	return tx.Commit()
 }
 
 ////// POSTGRES ///////
 
 func (l *PgHandler) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS logs (
-		id SERIAL PRIMARY KEY,
-		init_time_ms BIGINT NOT NULL,
-		end_time_ms BIGINT,
-		category TEXT NOT NULL,
-		description TEXT,
-	    constraint unique_task unique (init_time_ms, category)
-	);`
-
-	_, err := l.db.Exec(schema)
+	_, err := (&Schema{db: l.db, dialect: pgDialect}).Migrate()
 	return err
 }
 
 func (l *PgHandler) Insert(data *LogEntry) error {
 	stmt := `
-	INSERT INTO logs (init_time_ms, end_time_ms, category, description)
-	VALUES ($1, $2, $3, $4)
+	INSERT INTO logs (init_time_ms, end_time_ms, category, description, warnings, overtime_ms, notify_failed,
+		laps, task_id, planned_end_time_ms, outcome, hostname, username, unlimited)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	ON CONFLICT ON CONSTRAINT unique_task 
-	DO UPDATE SET end_time_ms = EXCLUDED.end_time_ms`
+	DO UPDATE SET end_time_ms = EXCLUDED.end_time_ms, description = EXCLUDED.description,
+		warnings = EXCLUDED.warnings,
+		overtime_ms = EXCLUDED.overtime_ms, notify_failed = EXCLUDED.notify_failed, laps = EXCLUDED.laps,
+		task_id = EXCLUDED.task_id, planned_end_time_ms = EXCLUDED.planned_end_time_ms, outcome = EXCLUDED.outcome,
+		hostname = EXCLUDED.hostname, username = EXCLUDED.username, unlimited = EXCLUDED.unlimited`
 
-	_, err := l.db.Exec(stmt, data.InitTime, data.EndTime, data.Category, data.Description)
-	return err
+	return l.insert(pgDialect, stmt, data)
 }
 
 func (l *PgHandler) Exists(entry *LogEntry) (bool, error) {
@@ -123,30 +295,41 @@ func (l *PgHandler) IsFinished(entry *LogEntry) (bool, error) {
 	return finished, nil
 }
 
+func (l *PgHandler) TotalTime(category string, since int64) (int64, error) {
+	query := `
+	SELECT COALESCE(SUM(end_time_ms - init_time_ms), 0)
+	FROM logs
+	WHERE category = $1 AND init_time_ms >= $2 AND end_time_ms > init_time_ms`
+
+	var total int64
+	if err := l.db.QueryRow(query, category, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("computing total time: %w", err)
+	}
+	return total, nil
+}
+
+func (l *PgHandler) Query(filter Filter) ([]LogEntry, error) {
+	return l.query(pgDialect, filter)
+}
+
+func (l *PgHandler) Delete(entry *LogEntry) error {
+	return l.delete(pgDialect, entry)
+}
+
 ////// SQLITE ///////
 
 func (l *SqliteHandler) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS logs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		init_time_ms BIGINT NOT NULL,
-		end_time_ms BIGINT,
-		category TEXT NOT NULL,
-		description TEXT
-	);
-	CREATE UNIQUE INDEX IF NOT EXISTS unique_task ON logs(init_time_ms, category);`
-
-	_, err := l.db.Exec(schema)
+	_, err := (&Schema{db: l.db, dialect: sqliteDialect}).Migrate()
 	return err
 }
 
 func (l *SqliteHandler) Insert(data *LogEntry) error {
 	stmt := `
-	INSERT OR REPLACE INTO logs (init_time_ms, end_time_ms, category, description)
-	VALUES (?, ?, ?, ?)` 
+	INSERT OR REPLACE INTO logs (init_time_ms, end_time_ms, category, description, warnings, overtime_ms,
+		notify_failed, laps, task_id, planned_end_time_ms, outcome, hostname, username, unlimited)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := l.db.Exec(stmt, data.InitTime, data.EndTime, data.Category, data.Description)
-	return err
+	return l.insert(sqliteDialect, stmt, data)
 }
 
 func (l *SqliteHandler) Exists(entry *LogEntry) (bool, error) {
@@ -181,4 +364,23 @@ func (l *SqliteHandler) IsFinished(entry *LogEntry) (bool, error) {
 	return finished, nil
 }
 
+func (l *SqliteHandler) TotalTime(category string, since int64) (int64, error) {
+	query := `
+	SELECT COALESCE(SUM(end_time_ms - init_time_ms), 0)
+	FROM logs
+	WHERE category = ? AND init_time_ms >= ? AND end_time_ms > init_time_ms`
 
+	var total int64
+	if err := l.db.QueryRow(query, category, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("computing total time: %w", err)
+	}
+	return total, nil
+}
+
+func (l *SqliteHandler) Query(filter Filter) ([]LogEntry, error) {
+	return l.query(sqliteDialect, filter)
+}
+
+func (l *SqliteHandler) Delete(entry *LogEntry) error {
+	return l.delete(sqliteDialect, entry)
+}
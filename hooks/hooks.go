@@ -0,0 +1,143 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Event string
+
+const (
+	Start  Event = "on-start"
+	Finish Event = "on-finish"
+	Cancel Event = "on-cancel"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Task is passed to the hooks as JSON on stdin and as JN_* environment variables
+type Task struct {
+	Event       Event    `json:"event"`
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	InitTime    int64    `json:"init_time_ms"`
+	EndTime     int64    `json:"end_time_ms"`
+	Deadline    int64    `json:"deadline_ms"` // Zero in unlimited mode
+	Pid         int      `json:"pid"`
+}
+
+type Runner struct {
+	scripts map[Event]string
+	timeout time.Duration
+}
+
+// NewRunner resolves the hook scripts from the config. Each event uses the
+// script from its HOOK_ON_* key, or the file named after the event inside
+// HOOKS_DIR (~/.config/jn/hooks by default).
+func NewRunner(cfg map[string]string) *Runner {
+	dir := cfg["HOOKS_DIR"]
+	if dir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "jn", "hooks")
+		}
+	}
+
+	timeout := defaultTimeout
+	if value := cfg["HOOK_TIMEOUT"]; value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			timeout = parsed
+		} else {
+			log.Printf("Invalid HOOK_TIMEOUT %q, using %s", value, defaultTimeout)
+		}
+	}
+
+	runner := &Runner{scripts: make(map[Event]string), timeout: timeout}
+	for _, event := range []Event{Start, Finish, Cancel} {
+		key := "HOOK_" + strings.ToUpper(strings.ReplaceAll(string(event), "-", "_"))
+		if script := cfg[key]; script != "" {
+			runner.scripts[event] = script
+		} else if dir != "" {
+			runner.scripts[event] = filepath.Join(dir, string(event))
+		}
+	}
+
+	return runner
+}
+
+// Run executes the hook of the task event, if any, and logs its result
+func (r *Runner) Run(task Task) {
+	script := r.scripts[task.Event]
+	if script == "" {
+		return
+	}
+
+	if info, err := os.Stat(script); err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		// Missing or not executable hooks are silently ignored
+		return
+	}
+
+	output, err := r.exec(script, task)
+	output = strings.TrimSpace(output)
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("Hook %s timed out after %s: %s", task.Event, r.timeout, output)
+	case errors.As(err, &exitErr):
+		log.Printf("Hook %s exited with status %d: %s", task.Event, exitErr.ExitCode(), output)
+	case err != nil:
+		log.Printf("Error running hook %s: %s", task.Event, err)
+	case output != "":
+		log.Printf("Hook %s: %s", task.Event, output)
+	}
+}
+
+func (r *Runner) exec(script string, task Task) (string, error) {
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return "", fmt.Errorf("encoding task: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(os.Environ(), env(task)...)
+	// Children of the script may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	return output.String(), err
+}
+
+func env(task Task) []string {
+	return []string{
+		"JN_EVENT=" + string(task.Event),
+		"JN_CATEGORY=" + task.Category,
+		"JN_DESCRIPTION=" + task.Description,
+		"JN_TAGS=" + strings.Join(task.Tags, ","),
+		"JN_INIT_TIME_MS=" + strconv.FormatInt(task.InitTime, 10),
+		"JN_END_TIME_MS=" + strconv.FormatInt(task.EndTime, 10),
+		"JN_DEADLINE_MS=" + strconv.FormatInt(task.Deadline, 10),
+		"JN_PID=" + strconv.Itoa(task.Pid),
+	}
+}
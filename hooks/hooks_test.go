@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("writing script: %v", err)
+	}
+	return path
+}
+
+func TestNewRunner(t *testing.T) {
+	cfg := map[string]string{
+		"HOOKS_DIR":      "/hooks",
+		"HOOK_ON_CANCEL": "/custom/cancel.sh",
+		"HOOK_TIMEOUT":   "2s",
+	}
+
+	runner := NewRunner(cfg)
+
+	if runner.scripts[Start] != "/hooks/on-start" {
+		t.Errorf("start hook = %s, want /hooks/on-start", runner.scripts[Start])
+	}
+
+	if runner.scripts[Cancel] != "/custom/cancel.sh" {
+		t.Errorf("cancel hook = %s, want /custom/cancel.sh", runner.scripts[Cancel])
+	}
+
+	if runner.timeout != 2*time.Second {
+		t.Errorf("timeout = %s, want 2s", runner.timeout)
+	}
+}
+
+func TestExec(t *testing.T) {
+	dir := t.TempDir()
+	runner := &Runner{timeout: time.Second}
+	task := Task{Event: Finish, Category: "testing", InitTime: 10, EndTime: 20}
+
+	script := writeScript(t, dir, "env", "#!/bin/sh\necho $JN_EVENT $JN_CATEGORY $JN_END_TIME_MS\ncat\n")
+	output, err := runner.exec(script, task)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "on-finish testing 20\n") {
+		t.Errorf("environment not received, output: %q", output)
+	}
+
+	if !strings.Contains(output, `"category":"testing"`) {
+		t.Errorf("JSON not received on stdin, output: %q", output)
+	}
+
+	slow := writeScript(t, dir, "slow", "#!/bin/sh\nsleep 5\n")
+	runner.timeout = 100 * time.Millisecond
+	if _, err := runner.exec(slow, task); err == nil {
+		t.Error("expected timeout error")
+	}
+}
@@ -5,15 +5,24 @@ import (
 	"just-notify/commands"
 	"just-notify/config"
 	"just-notify/database"
+	"just-notify/hooks"
 	"just-notify/notification"
+	"just-notify/ui"
 	"log"
 	"os"
 	"os/signal"
+	"os/user"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"text/template"
 	"time"
 )
 
+// extendStep is the time added with the + key
+const extendStep = 5 * time.Minute
+
 type app struct {
 	wg          sync.WaitGroup
 	closeSignal chan bool
@@ -27,6 +36,15 @@ func main() {
 		cfg:         config.LoadConfig(),
 	}
 
+	if len(os.Args) > 1 {
+		if run, ok := commands.Subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:], app.cfg); err != nil {
+				log.Fatalln(err)
+			}
+			os.Exit(0)
+		}
+	}
+
 	args, err := config.ParseArgs(app.cfg)
 
 	if err != nil {
@@ -46,18 +64,265 @@ func main() {
 
 		log.Printf("Process %d terminated sucessfully\n", pid)
 		os.Exit(0)
-	} else {
-		// Create the pid file
-		commands.StorePID(args.Category)
 	}
 
+	// Commands sent by other jn processes (e.g. jn ack) arrive with SIGUSR1,
+	// whose default action would kill the timer, so it is caught before the
+	// pid file lets them find it. They are read once the task is set up.
+	controlChan := make(chan os.Signal, 1)
+	signal.Notify(controlChan, syscall.SIGUSR1)
+
+	// Create the pid file
+	commands.StorePID(args.Category)
+
+	// The start of the task, the percentage warnings are relative to it
+	currentTime := time.Now().UnixMilli()
+
 	var millis int64
+	var warnings []notification.Warning
+	var nagInterval time.Duration
 	if !args.Unlimited {
 		var err error
 		millis, err = commands.GetTime(args.Time)
 		if err != nil {
 			log.Fatalf("Error scheduling task: %v", err)
 		}
+
+		warnings, err = commands.ParseWarnings(args.Warn, currentTime, millis)
+		if err != nil {
+			log.Fatalf("Error scheduling warnings: %v", err)
+		}
+
+		if args.Nag != "" {
+			nagInterval, err = commands.ParseDuration(args.Nag)
+			if err != nil {
+				log.Fatalf("Error parsing nag interval: %v", err)
+			}
+		}
+	}
+
+	templates, err := notification.NewTemplates(args.TitleTmpl, args.BodyTmpl)
+	if err != nil {
+		log.Fatalf("Error in notification templates: %v", err)
+	}
+
+	theme, err := ui.LoadTheme(app.cfg)
+	if err != nil {
+		log.Fatalf("Error loading progress theme: %v", err)
+	}
+
+	notifier, err := notification.NewChain(args.Notifiers, app.cfg)
+	if err != nil {
+		log.Fatalf("Error configuring notifiers: %v", err)
+	}
+
+	// Set when a completion notification could not be shown by any notifier
+	var notifyFailed atomic.Bool
+
+	timer := ui.NewTimer(currentTime, millis)
+	taskID := database.NewID()
+	hostname, username := identity()
+
+	// publishTask shares the state of the task with other jn processes
+	publishTask := func() {
+		state := timer.State()
+		if err := commands.StoreTask(commands.TaskInfo{
+			Pid:         os.Getpid(),
+			Category:    args.Category,
+			Description: args.Description,
+			InitTime:    currentTime,
+			Planned:     state.Planned.Milliseconds(),
+			Elapsed:     state.Elapsed.Milliseconds(),
+			Paused:      state.Paused,
+			UpdatedAt:   time.Now().UnixMilli(),
+		}); err != nil {
+			log.Printf("Error publishing task: %s", err)
+		}
+	}
+	publishTask()
+
+	// The status files list every running task, rewritten each second
+	var statusTmpl *template.Template
+	if args.StatusFile {
+		statusTmpl, err = commands.NewStatusTemplate(app.cfg["STATUS_FORMAT"])
+		if err != nil {
+			log.Fatalf("Error in status format: %v", err)
+		}
+	}
+	writeStatus := func() {
+		if statusTmpl == nil {
+			return
+		}
+		if err := commands.WriteStatusFiles(statusTmpl); err != nil {
+			log.Printf("Error writing status files: %s", err)
+		}
+	}
+
+	// Set when the keyboard is read, the terminal settings are restored on exit
+	restoreTerminal := func() error { return nil }
+
+	// cleanup removes the files of the task and drops it from the status
+	cleanup := func() {
+		if err := restoreTerminal(); err != nil {
+			log.Printf("Error restoring the terminal: %s", err)
+		}
+		commands.RemovePID(args.Category)
+		writeStatus()
+	}
+	defer cleanup()
+
+	if statusTmpl != nil {
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				writeStatus()
+				<-ticker.C
+			}
+		}()
+	}
+
+	// emit writes a JSON event, only with --output json
+	var events *ui.EventWriter
+	if args.Output == "json" {
+		events = ui.NewEventWriter(os.Stdout, timer, taskID, args.Category, currentTime)
+	}
+	emit := func(event, message string) {
+		if events != nil {
+			events.Emit(event, message)
+		}
+	}
+
+	// Laps split the task, they are logged with it
+	var lapsMu sync.Mutex
+	var laps []database.Lap
+	lapLines := make(chan string, 8)
+	recordLap := func(note string) {
+		state := timer.State()
+
+		lapsMu.Lock()
+		var previous time.Duration
+		if len(laps) > 0 {
+			previous = time.Duration(laps[len(laps)-1].Elapsed) * time.Millisecond
+		}
+		laps = append(laps, database.Lap{
+			Time:    time.Now().UnixMilli(),
+			Elapsed: state.Elapsed.Milliseconds(),
+			Note:    note,
+		})
+		number := len(laps)
+		lapsMu.Unlock()
+
+		if events != nil {
+			emit(ui.EventLap, note)
+			return
+		}
+
+		text := fmt.Sprintf("Lap %d  %s  +%s", number, ui.FormatClock(state.Elapsed), ui.FormatClock(state.Elapsed-previous))
+		if note != "" {
+			text += "  " + note
+		}
+
+		// Printed above the elapsed time in unlimited mode
+		if args.Unlimited {
+			select {
+			case lapLines <- text:
+				return
+			default:
+			}
+		}
+		log.Println(text)
+	}
+
+	// Set from the keyboard: f finishes the task now and q cancels it
+	// without logging
+	var finishNow, discard atomic.Bool
+
+	// Set when the task is stopped with SIGTERM, e.g. by jn --kill
+	var terminated atomic.Bool
+	stopTask := func() {
+		select {
+		case app.closeSignal <- true:
+		default:
+		}
+	}
+
+	// Notes typed with n are appended to the description
+	var notesMu sync.Mutex
+	var notes []string
+	description := func() string {
+		notesMu.Lock()
+		defer notesMu.Unlock()
+
+		parts := notes
+		if args.Description != "" {
+			parts = append([]string{args.Description}, notes...)
+		}
+		return strings.Join(parts, "; ")
+	}
+
+	// Keys are read while the timer runs in the foreground of a terminal
+	var keyboard *ui.Keyboard
+	if events == nil && ui.IsKeyboardAvailable() {
+		if keyboard, err = ui.NewKeyboard(theme); err != nil {
+			log.Printf("Error reading the keyboard: %s", err)
+			keyboard = nil
+		} else {
+			restoreTerminal = keyboard.Close
+
+			help := "Keys: p pause  + add 5m  n note  f finish  q cancel"
+			if args.Unlimited {
+				help = "Keys: p pause  enter lap  l lap with note  n note  f finish  q cancel"
+			}
+			fmt.Println(help)
+
+			go func() {
+				for key := range keyboard.Keys() {
+					switch key {
+					case "p":
+						if timer.State().Paused {
+							timer.Resume()
+						} else {
+							timer.Pause()
+						}
+						publishTask()
+					case "+":
+						if !args.Unlimited {
+							timer.Extend(extendStep)
+							publishTask()
+						}
+					case "n":
+						keyboard.Prompt("Note", func(text string) {
+							if text == "" {
+								return
+							}
+							notesMu.Lock()
+							notes = append(notes, text)
+							notesMu.Unlock()
+						})
+					case "l":
+						if args.Unlimited {
+							keyboard.Prompt("Lap note", recordLap)
+						}
+					case "\r", "\n":
+						if args.Unlimited {
+							recordLap("")
+						}
+					case "f":
+						finishNow.Store(true)
+						stopTask()
+					case "q":
+						discard.Store(true)
+						stopTask()
+					}
+				}
+			}()
+		}
+	}
+
+	if events != nil {
+		emit(ui.EventScheduled, args.Time)
+	} else if !args.Unlimited {
 		fmt.Printf("Alert scheduled for %s\n", args.Time)
 	}
 
@@ -65,6 +330,52 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
 
+	ackChan := make(chan struct{}, 1)
+	go func() {
+		for range controlChan {
+			cmds, err := commands.ReadControl(args.Category)
+			if err != nil {
+				log.Printf("Error reading commands: %s", err)
+				continue
+			}
+
+			for _, cmd := range cmds {
+				name, value, _ := strings.Cut(cmd, " ")
+				switch name {
+				case "ack":
+					// Only the reminders after the deadline are acknowledged
+					if !timer.State().Completed() {
+						log.Printf("Acknowledgement ignored, the task has not reached its deadline")
+						continue
+					}
+					select {
+					case ackChan <- struct{}{}:
+					default:
+					}
+				case "lap":
+					recordLap(value)
+				case "pause":
+					timer.Pause()
+					emit(ui.EventPaused, "")
+				case "resume":
+					timer.Resume()
+					emit(ui.EventResumed, "")
+				case "extend":
+					d, err := time.ParseDuration(value)
+					if err != nil {
+						log.Printf("Invalid extension %q: %s", value, err)
+						continue
+					}
+					timer.Extend(d)
+					emit(ui.EventExtended, d.String())
+				default:
+					log.Printf("Unknown command received: %s", cmd)
+				}
+			}
+			publishTask()
+		}
+	}()
+
 	// Create error channel for goroutine errors
 	errChan := make(chan error, 1)
 
@@ -72,62 +383,218 @@ func main() {
 	go func() {
 		defer app.wg.Done()
 
-		var logger database.Logger
-		var err error
-		if args.UseDatabase {
-			logger, err = database.NewLogger(args.ConnString, true)
-		} else {
-			logger, err = database.NewLogger(args.CsvPath, false)
+		logger, err := openLogger(args, app.cfg)
+		if err != nil {
+			cleanup()
+			log.Fatalf("Error opening log: %s", err)
 		}
 
-		currentTime := time.Now().UnixMilli()
-
 		exists, err := logger.Exists(&database.LogEntry{
 			InitTime: currentTime,
 			Category: args.Category,
 		})
 
 		if exists {
+			cleanup()
 			log.Fatalf("The task with time %d and category %s already exists", currentTime, args.Category)
 		}
 
 		if err != nil {
+			cleanup()
 			log.Fatalf("Error checking task: %s", err)
 		}
 
 		// Initialize the data before scheduling the task; this allows tracking if any
 		// tasks exist and prevents data loss when the task is not finalized gracefully.
 		if err := logger.Log(&database.LogEntry{
-			InitTime:    currentTime,
-			Category:    args.Category,
-			Description: args.Description,
+			ID:             taskID,
+			InitTime:       currentTime,
+			PlannedEndTime: millis,
+			Category:       args.Category,
+			Description:    args.Description,
+			Tags:           args.Tags,
+			Hostname:       hostname,
+			User:           username,
+			Unlimited:      args.Unlimited,
 		}); err != nil {
 			errChan <- fmt.Errorf("failed to log initial entry: %w", err)
 			return
 		}
 
-		notification.Schedule(!args.Unlimited, app.closeSignal, currentTime, millis, func(now, epochMillis int64) {
-			if !args.Headless {
-				notification.Notify(args.Notif, fmt.Sprintf("Time completed: %s", args.Category))
+		runner := hooks.NewRunner(app.cfg)
+		task := hooks.Task{
+			Category:    args.Category,
+			Description: args.Description,
+			Tags:        args.Tags,
+			InitTime:    currentTime,
+			Deadline:    millis,
+			Pid:         os.Getpid(),
+		}
+
+		task.Event = hooks.Start
+		runner.Run(task)
+
+		var firedMu sync.Mutex
+		var fired []string
+
+		// completionMessage renders the notification templates for a task
+		// finished at end
+		completionMessage := func(end int64) notification.Message {
+			now := time.Now()
+			startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+			today, err := logger.TotalTime(args.Category, startOfDay.UnixMilli())
+			if err != nil {
+				log.Printf("Error computing today's total: %s", err)
+			}
+
+			data := notification.MessageData{
+				Title:       args.Notif,
+				Category:    args.Category,
+				Description: args.Description,
+				Elapsed:     time.Duration(end-currentTime) * time.Millisecond,
+				Start:       time.UnixMilli(currentTime),
+				End:         time.UnixMilli(end),
+				Today:       time.Duration(today+end-currentTime) * time.Millisecond,
 			}
 
+			if state := timer.State(); state.Planned != 0 {
+				data.Planned = state.Planned
+				data.Overtime = max(0, state.Elapsed-state.Planned)
+			}
+
+			msg := notification.Message{Category: args.Category, Elapsed: data.Elapsed}
+
+			msg.Title, msg.Body, err = templates.Render(data)
 			if err != nil {
-				errChan <- fmt.Errorf("failed to create logger: %w", err)
-				return
+				log.Printf("Error rendering notification: %s", err)
+				msg.Title, msg.Body = args.Notif, fmt.Sprintf("Time completed: %s", args.Category)
+			}
+			return msg
+		}
+
+		opts := notification.Options{
+			ProgressBar: !args.Unlimited,
+			Warnings:    warnings,
+			OnWarning: func(w notification.Warning) {
+				if !args.Headless {
+					left := timer.State().Remaining().Round(time.Second)
+					if err := notifier.Notify(notification.Message{
+						Title:    fmt.Sprintf("Heads up: %s", args.Category),
+						Body:     fmt.Sprintf("%s left", left),
+						Category: args.Category,
+						Elapsed:  time.Since(time.UnixMilli(currentTime)),
+					}); err != nil {
+						log.Printf("Error showing warning %s: %s", w.Label, err)
+					}
+				}
+
+				firedMu.Lock()
+				fired = append(fired, w.Label)
+				firedMu.Unlock()
+				emit(ui.EventWarning, w.Label)
+			},
+			Timer:       timer,
+			Theme:       theme,
+			Label:       args.Category,
+			Big:         args.Big,
+			Events:      events,
+			Lines:       lapLines,
+			Keyboard:    keyboard,
+			NagInterval: nagInterval,
+			OnNag: func(overtime time.Duration) {
+				if args.Headless {
+					return
+				}
+
+				msg := completionMessage(time.Now().UnixMilli())
+				if overtime > 0 {
+					msg.Body = fmt.Sprintf("%s (%s over, run jn ack -c %s)", msg.Body, overtime, args.Category)
+				}
+				if err := notifier.Notify(msg); err != nil {
+					log.Printf("Error showing notification: %s", err)
+					notifyFailed.Store(true)
+				}
+			},
+			Ack: ackChan,
+		}
+
+		notification.Schedule(opts, app.closeSignal, currentTime, millis, func(now, epochMillis int64) {
+			// In nag mode the user has already been notified, and tasks ended from
+			// the keyboard need no notification
+			if !args.Headless && nagInterval == 0 && !finishNow.Load() && !discard.Load() {
+				if err := notifier.Notify(completionMessage(epochMillis)); err != nil {
+					log.Printf("Error showing notification: %s", err)
+					notifyFailed.Store(true)
+				}
+			}
+
+			state := timer.State()
+
+			var overtime int64
+			if nagInterval > 0 && state.Elapsed > state.Planned {
+				overtime = (state.Elapsed - state.Planned).Milliseconds()
 			}
+
+			outcome := taskOutcome(state, finishNow.Load(), terminated.Load())
+
+			// Tasks stopped before their deadline were cancelled, unlimited
+			// tasks have none
+			task.Event = hooks.Finish
+			if discard.Load() || (outcome != database.OutcomeCompleted && state.Planned != 0) {
+				task.Event = hooks.Cancel
+				emit(ui.EventKilled, "")
+			} else {
+				emit(ui.EventFinished, "")
+			}
+			task.EndTime = epochMillis
+			task.Description = description()
+			runner.Run(task)
+
 			defer logger.Close()
 
+			if discard.Load() {
+				if err := logger.Delete(&database.LogEntry{InitTime: now, Category: args.Category}); err != nil {
+					errChan <- fmt.Errorf("failed to remove entry: %w", err)
+					return
+				}
+				log.Println("Task cancelled, entry removed.")
+				return
+			}
+
+			firedMu.Lock()
+			defer firedMu.Unlock()
+			lapsMu.Lock()
+			defer lapsMu.Unlock()
+
+			var plannedEnd int64
+			if state.Planned != 0 {
+				plannedEnd = now + state.Planned.Milliseconds()
+			}
+
 			if err := logger.Log(&database.LogEntry{
-				InitTime:    now,
-				EndTime:     epochMillis,
-				Category:    args.Category,
-				Description: args.Description,
+				ID:             taskID,
+				InitTime:       now,
+				EndTime:        epochMillis,
+				PlannedEndTime: plannedEnd,
+				Category:       args.Category,
+				Description:    description(),
+				Warnings:       fired,
+				Overtime:       overtime,
+				NotifyFailed:   notifyFailed.Load(),
+				Laps:           laps,
+				Tags:           args.Tags,
+				Outcome:        outcome,
+				Hostname:       hostname,
+				User:           username,
+				Unlimited:      args.Unlimited,
 			}); err != nil {
 				errChan <- fmt.Errorf("failed to log entry: %w", err)
 				return
 			}
 
 			log.Println("Entry logged successfully.")
+			emit(ui.EventLogged, "")
 		})
 	}()
 
@@ -144,6 +611,7 @@ func main() {
 		select {
 		case sig := <-sigChan:
 			log.Printf("\nReceived signal: %v", sig)
+			terminated.Store(sig == syscall.SIGTERM)
 
 			// Send close signal with timeout
 			select {
@@ -155,9 +623,77 @@ func main() {
 			}
 		case err := <-errChan:
 			log.Printf("Error during execution: %v", err)
+			emit(ui.EventError, err.Error())
 		}
 	}()
 
 	<-done
+	if notifyFailed.Load() {
+		cleanup()
+		log.Println("Shutdown without notifying the user")
+		os.Exit(2)
+	}
+
 	log.Println("Shutdown successfully")
 }
+
+// identity returns the machine and the user logged with the tasks
+func identity() (string, string) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Printf("Error reading the hostname: %s", err)
+	}
+
+	username := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+
+	return hostname, username
+}
+
+// taskOutcome tells how a task ended from the way it was stopped, the
+// notification failures are recorded apart. Ctrl-C is the usual end of an
+// unlimited task.
+func taskOutcome(state ui.TimerState, finished, terminated bool) database.Outcome {
+	switch {
+	case finished || (state.Planned != 0 && state.Completed()):
+		return database.OutcomeCompleted
+	case terminated:
+		return database.OutcomeKilled
+	case state.Planned == 0:
+		return database.OutcomeCompleted
+	default:
+		return database.OutcomeSignal
+	}
+}
+
+// openLogger opens the CSV file or the database, the database with the spool
+// of SPOOL_PATH for the entries it fails to insert, every entry while it is
+// unreachable. The entries spooled by previous runs are inserted first.
+func openLogger(args *config.ArgsCli, cfg map[string]string) (database.Logger, error) {
+	if !args.UseDatabase {
+		return database.NewLogger(args.CsvPath, false)
+	}
+
+	spool, err := database.NewSpool(cfg["SPOOL_PATH"])
+	if err != nil {
+		return nil, err
+	}
+
+	// Offline, the task is spooled and synced by the next run
+	logger := database.NewSpooled(args.ConnString, spool)
+	if logger.Offline() {
+		return logger, nil
+	}
+
+	result, err := logger.Sync()
+	if err != nil {
+		log.Printf("Error syncing %s, retrying on the next run: %s", spool.Path(), err)
+	}
+	if result.Synced > 0 {
+		log.Printf("Synced %d spooled entries from %s", result.Synced, spool.Path())
+	}
+
+	return logger, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"just-notify/database"
+	"just-notify/ui"
+	"testing"
+	"time"
+)
+
+func TestTaskOutcome(t *testing.T) {
+	scheduled := ui.TimerState{Planned: time.Hour, Elapsed: 10 * time.Minute}
+	reached := ui.TimerState{Planned: time.Hour, Elapsed: time.Hour}
+	unlimited := ui.TimerState{Elapsed: time.Hour}
+
+	tests := []struct {
+		name       string
+		state      ui.TimerState
+		finished   bool
+		terminated bool
+		want       database.Outcome
+	}{
+		{"Deadline reached", reached, false, false, database.OutcomeCompleted},
+		{"Finished with f", scheduled, true, false, database.OutcomeCompleted},
+		{"Ctrl-C before the deadline", scheduled, false, false, database.OutcomeSignal},
+		{"Killed before the deadline", scheduled, false, true, database.OutcomeKilled},
+		{"Unlimited stopped with Ctrl-C", unlimited, false, false, database.OutcomeCompleted},
+		{"Unlimited killed", unlimited, false, true, database.OutcomeKilled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taskOutcome(tt.state, tt.finished, tt.terminated); got != tt.want {
+				t.Errorf("taskOutcome() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,60 +3,218 @@ package notification
 import (
 	"fmt"
 	"just-notify/ui"
-	"os/exec"
-	"runtime"
+	"os"
 	"time"
 )
 
-func Notify(title, message string) error {
-	switch runtime.GOOS {
-	case "darwin":
-		return exec.Command("terminal-notifier", "-title", title, "-message", message).Run()
-	case "linux":
-		return exec.Command("notify-send", title, message).Run()
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
-	}
+// Warning is a pre-alert fired before the deadline of a task.
+type Warning struct {
+	At    int64  // Epoch millis when the warning must fire
+	Label string // Original spec, e.g. "5m" or "50%"
+}
+
+type Options struct {
+	ProgressBar bool
+	Warnings    []Warning
+	OnWarning   func(Warning)
+
+	// Nag mode: once the deadline is reached OnNag is called every
+	// NagInterval until Ack receives or closeSignal is sent.
+	NagInterval time.Duration
+	OnNag       func(overtime time.Duration)
+	Ack         <-chan struct{}
+
+	// Timer allows pausing and extending the task while it runs, one is
+	// created from the scheduled times when it is nil.
+	Timer *ui.Timer
+
+	Theme *ui.Theme
+	Label string // Shown by the progress bar when the theme layout has it
+	Big   bool   // Large countdown clock instead of the progress bar
+
+	// Events replaces the human output with JSON events when it is set
+	Events *ui.EventWriter
+
+	// Lines are printed above the elapsed time of unlimited tasks, e.g. laps
+	Lines <-chan string
+
+	// Keyboard shows its prompt in place of the progress, it may be nil
+	Keyboard *ui.Keyboard
 }
 
-func Schedule(enableProgressBar bool, closeSignal chan bool, now, epochMillis int64, action func(int64, int64)) {
+func Schedule(opts Options, closeSignal chan bool, now, epochMillis int64, action func(int64, int64)) {
 	if epochMillis != 0 && epochMillis < now {
-		fmt.Println("Warning: Target time is in the past")
+		// Stdout only carries the events in the JSON output
+		out := os.Stdout
+		if opts.Events != nil {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, "Warning: Target time is in the past")
 		return
 	}
 
-	if enableProgressBar {
-		doneChan := make(chan struct{})
+	timer := opts.Timer
+	if timer == nil {
+		timer = ui.NewTimer(now, epochMillis)
+	}
+
+	stopWarnings := make(chan struct{})
+	defer close(stopWarnings)
+	go watchWarnings(timer, epochMillis, opts.Warnings, opts.OnWarning, stopWarnings)
+
+	if opts.Events != nil {
+		if completed := ui.EventTicks(closeSignal, timer, opts.Events); completed && opts.NagInterval > 0 {
+			nag(opts, closeSignal, timer)
+		}
+		action(now, time.Now().UnixMilli())
+		return
+	}
+
+	if opts.ProgressBar {
+		render := ui.ProgressBar
+		if opts.Big {
+			render = ui.BigClock
+		}
+
+		doneChan := make(chan bool)
 		go func() {
-			ui.ProgressBar(closeSignal, now, epochMillis)
-			close(doneChan)
+			doneChan <- render(closeSignal, timer, opts.Theme, opts.Label, opts.Keyboard)
 		}()
-		<-doneChan
+		if completed := <-doneChan; completed && opts.NagInterval > 0 {
+			nag(opts, closeSignal, timer)
+		}
 		action(now, time.Now().UnixMilli())
 		return
 	}
 
+	line := ui.NewStatusLine(os.Stdout)
+	defer line.Close()
+
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-closeSignal:
+			line.Done(ui.ElapsedLine(timer.State()))
 			action(now, time.Now().UnixMilli())
 			return
+		case text := <-opts.Lines:
+			line.Print(text)
+		case <-opts.Keyboard.Changed():
+			line.Update(opts.Keyboard.Status(ui.ElapsedLine(timer.State())))
 		case t := <-ticker.C:
 			current := t.UnixMilli()
-			elapsed := time.Since(time.UnixMilli(now)).Round(time.Second)
-			hours := int(elapsed.Hours())
-			minutes := int(elapsed.Minutes()) % 60
-			seconds := int(elapsed.Seconds()) % 60
-			fmt.Printf("\rTime elapsed: %02d:%02d:%02d", hours, minutes, seconds)
-
-			if epochMillis != 0 && current >= epochMillis {
-				fmt.Println() // Add newline before exiting
+			state := timer.State()
+
+			if state.Completed() {
+				line.Done(ui.ElapsedLine(state))
+				if opts.NagInterval > 0 {
+					nag(opts, closeSignal, timer)
+					current = time.Now().UnixMilli()
+				}
 				action(now, current)
 				return
 			}
+
+			line.Update(opts.Keyboard.Status(ui.ElapsedLine(state)))
+		}
+	}
+}
+
+// watchWarnings fires each warning once the remaining time of the timer
+// reaches the time left it was scheduled for with the original deadline, so
+// pauses and extensions delay it. Warnings already passed are skipped. It
+// returns when all of them were fired or when stop is closed.
+func watchWarnings(timer *ui.Timer, deadline int64, warnings []Warning, onWarning func(Warning), stop chan struct{}) {
+	if onWarning == nil || len(warnings) == 0 {
+		return
+	}
+
+	// Skip the warnings already passed
+	remaining := timer.State().Remaining()
+	for len(warnings) > 0 && time.Duration(deadline-warnings[0].At)*time.Millisecond >= remaining {
+		warnings = warnings[1:]
+	}
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for len(warnings) > 0 {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			state := timer.State()
+			w := warnings[0]
+			if state.Remaining() <= time.Duration(deadline-w.At)*time.Millisecond {
+				warnings = warnings[1:]
+				onWarning(w)
+			}
+		}
+	}
+}
+
+// nag calls OnNag right away and then every NagInterval, showing the overtime
+// since the deadline, until the task is acknowledged or closed.
+func nag(opts Options, closeSignal chan bool, timer *ui.Timer) {
+	overtime := func() time.Duration {
+		state := timer.State()
+		return (state.Elapsed - state.Planned).Round(time.Second)
+	}
+
+	remind := func(overtime time.Duration) {
+		if opts.Events != nil {
+			opts.Events.Emit(ui.EventNag, "")
+		}
+		if opts.OnNag != nil {
+			opts.OnNag(overtime)
+		}
+	}
+
+	// show and done print the overtime, replaced by ticks in the JSON output
+	var line *ui.StatusLine
+	show := func(text string) {
+		if opts.Events != nil {
+			opts.Events.Emit(ui.EventTick, "")
+			return
+		}
+		if line == nil {
+			line = ui.NewStatusLine(os.Stdout)
+		}
+		line.Update(opts.Keyboard.Status(text))
+	}
+	done := func(text string) {
+		if opts.Events == nil {
+			if line == nil {
+				line = ui.NewStatusLine(os.Stdout)
+			}
+			line.Done(text)
+		}
+	}
+
+	remind(0)
+
+	reminder := time.NewTicker(opts.NagInterval)
+	defer reminder.Stop()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-opts.Ack:
+			done("Acknowledged after " + ui.FormatClock(overtime()) + " overtime")
+			return
+		case <-closeSignal:
+			done("Overtime: " + ui.FormatClock(overtime()))
+			return
+		case <-reminder.C:
+			remind(overtime())
+		case <-ticker.C:
+			show("Overtime: " + ui.FormatClock(overtime()))
+		case <-opts.Keyboard.Changed():
+			show("Overtime: " + ui.FormatClock(overtime()))
 		}
 	}
 }
@@ -0,0 +1,92 @@
+package notification
+
+import (
+	"bytes"
+	"just-notify/ui"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startNag runs nag for a task past its deadline, it returns the overtimes
+// reminded so far and a channel closed when nag returns
+func startNag(interval time.Duration, ack chan struct{}, closeSignal chan bool) (func() []time.Duration, chan struct{}) {
+	init := time.Now().Add(-11 * time.Minute).UnixMilli()
+	timer := ui.NewTimer(init, init+10*time.Minute.Milliseconds())
+
+	var mu sync.Mutex
+	var reminders []time.Duration
+
+	var out bytes.Buffer
+	opts := Options{
+		NagInterval: interval,
+		Ack:         ack,
+		OnNag: func(overtime time.Duration) {
+			mu.Lock()
+			reminders = append(reminders, overtime)
+			mu.Unlock()
+		},
+		// Events keep the overtime off the test output
+		Events: ui.NewEventWriter(&out, timer, "task-1", "Focus", init),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		nag(opts, closeSignal, timer)
+		close(done)
+	}()
+
+	return func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Duration(nil), reminders...)
+	}, done
+}
+
+func TestNagInterval(t *testing.T) {
+	ack := make(chan struct{}, 1)
+	reminders, done := startNag(50*time.Millisecond, ack, make(chan bool))
+
+	time.Sleep(180 * time.Millisecond)
+	ack <- struct{}{}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nag() still running after the ack")
+	}
+
+	got := reminders()
+	if len(got) < 2 || len(got) > 5 {
+		t.Fatalf("got %d reminders in 180ms every 50ms, want about 4", len(got))
+	}
+	if got[0] != 0 {
+		t.Errorf("first reminder overtime = %s, want 0 right at the deadline", got[0])
+	}
+	if got[1] < time.Minute {
+		t.Errorf("reminder overtime = %s, want the time since the deadline", got[1])
+	}
+
+	// No reminder once acknowledged
+	count := len(got)
+	time.Sleep(120 * time.Millisecond)
+	if len(reminders()) != count {
+		t.Errorf("got %d reminders after the ack, want %d", len(reminders()), count)
+	}
+}
+
+func TestNagClose(t *testing.T) {
+	closeSignal := make(chan bool, 1)
+	reminders, done := startNag(time.Hour, nil, closeSignal)
+
+	closeSignal <- true
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nag() still running after the close signal")
+	}
+
+	if got := reminders(); len(got) != 1 {
+		t.Errorf("got %d reminders, want only the first one", len(got))
+	}
+}
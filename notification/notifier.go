@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Message is a notification about a task
+type Message struct {
+	Title    string
+	Body     string
+	Category string
+	Elapsed  time.Duration
+}
+
+// Notifier is a backend able to show a notification to the user
+type Notifier interface {
+	Name() string
+	Notify(msg Message) error
+}
+
+// Chain tries each notifier in order until one of them succeeds
+type Chain []Notifier
+
+var ErrNoNotifier = errors.New("no notifier succeeded")
+
+// notifiers holds the available backends by name, built from the config
+var notifiers = map[string]func(cfg map[string]string) Notifier{
+	"dbus":              func(map[string]string) Notifier { return dbusNotifier{} },
+	"notify-send":       func(map[string]string) Notifier { return commandNotifier{name: "notify-send"} },
+	"terminal-notifier": func(map[string]string) Notifier { return commandNotifier{name: "terminal-notifier"} },
+	"osascript":         func(map[string]string) Notifier { return osascriptNotifier{} },
+	"terminal":          newTerminalNotifier,
+	"tty":               newTTYNotifier,
+	"bell":              func(map[string]string) Notifier { return bellNotifier{} },
+	"stderr":            func(map[string]string) Notifier { return stderrNotifier{} },
+}
+
+// DefaultChain returns the platform notifiers, ending with the terminal ones.
+// Over SSH desktop notifications would show on the remote host, so the local
+// terminal emulator is notified instead.
+func DefaultChain() string {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return "terminal,bell,stderr"
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return "terminal-notifier,osascript,bell,stderr"
+	default:
+		return "dbus,notify-send,bell,stderr"
+	}
+}
+
+// NewChain builds a chain from a comma separated list of notifier names, an
+// empty spec uses the default chain of the platform.
+func NewChain(spec string, cfg map[string]string) (Chain, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultChain()
+	}
+
+	var chain Chain
+	for name := range strings.SplitSeq(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		newNotifier, ok := notifiers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown notifier: %s", name)
+		}
+		chain = append(chain, newNotifier(cfg))
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no notifier configured")
+	}
+
+	return chain, nil
+}
+
+// Notify returns ErrNoNotifier when every notifier failed, or when only the
+// stderr banner, which the user may never look at, was shown by a chain with
+// other notifiers.
+func (c Chain) Notify(msg Message) error {
+	for _, n := range c {
+		err := n.Notify(msg)
+		if err == nil {
+			if _, banner := n.(stderrNotifier); banner && len(c) > 1 {
+				return fmt.Errorf("%w, printed to stderr only", ErrNoNotifier)
+			}
+			return nil
+		}
+		log.Printf("Notifier %s failed: %s", n.Name(), err)
+	}
+
+	return ErrNoNotifier
+}
+
+type dbusNotifier struct{}
+
+func (dbusNotifier) Name() string { return "dbus" }
+
+func (dbusNotifier) Notify(msg Message) error {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return fmt.Errorf("no session bus")
+	}
+
+	return run(exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		"jn", "0", "", msg.Title, msg.Body, "[]", "{}", "-1"))
+}
+
+type commandNotifier struct {
+	name string
+}
+
+func (n commandNotifier) Name() string { return n.name }
+
+func (n commandNotifier) Notify(msg Message) error {
+	switch n.name {
+	case "terminal-notifier":
+		return run(exec.Command("terminal-notifier", "-title", msg.Title, "-message", msg.Body))
+	default:
+		return run(exec.Command(n.name, msg.Title, msg.Body))
+	}
+}
+
+type osascriptNotifier struct{}
+
+func (osascriptNotifier) Name() string { return "osascript" }
+
+func (osascriptNotifier) Notify(msg Message) error {
+	script := fmt.Sprintf("display notification %s with title %s", appleScriptString(msg.Body), appleScriptString(msg.Title))
+	return run(exec.Command("osascript", "-e", script))
+}
+
+// appleScriptString quotes a string literal of AppleScript, where only the
+// backslash and the double quote are escaped
+func appleScriptString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// bellNotifier rings the terminal bell of the controlling terminal
+type bellNotifier struct{}
+
+func (bellNotifier) Name() string { return "bell" }
+
+func (bellNotifier) Notify(Message) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening terminal: %w", err)
+	}
+	defer tty.Close()
+
+	_, err = tty.WriteString("\a")
+	return err
+}
+
+// stderrNotifier prints a banner to stderr
+type stderrNotifier struct{}
+
+func (stderrNotifier) Name() string { return "stderr" }
+
+func (stderrNotifier) Notify(msg Message) error {
+	_, err := fmt.Fprint(os.Stderr, "\n"+banner(msg))
+	return err
+}
+
+// banner frames the title and the body between two lines
+func banner(msg Message) string {
+	line := strings.Repeat("=", max(len(msg.Title), len(msg.Body))+4)
+	return fmt.Sprintf("%s\n  %s\n  %s\n%s\n", line, msg.Title, msg.Body, line)
+}
+
+// run executes a notifier command, including its output in the error
+func run(cmd *exec.Cmd) error {
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
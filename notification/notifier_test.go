@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeNotifier records its calls and fails when err is set
+type fakeNotifier struct {
+	name  string
+	err   error
+	calls *[]string
+}
+
+func (f fakeNotifier) Name() string { return f.name }
+
+func (f fakeNotifier) Notify(Message) error {
+	*f.calls = append(*f.calls, f.name)
+	return f.err
+}
+
+func TestNewChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"Default", "", strings.Split(DefaultChain(), ","), false},
+		{"Blank", "  ", strings.Split(DefaultChain(), ","), false},
+		{"Custom order", "bell, stderr,dbus", []string{"bell", "stderr", "dbus"}, false},
+		{"Empty names", "bell,,", []string{"bell"}, false},
+		{"Only separators", ",", nil, true},
+		{"Unknown", "bell,pigeon", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewChain(tt.spec, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, n := range chain {
+				got = append(got, n.Name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("NewChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainNotify(t *testing.T) {
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name      string
+		chain     func(calls *[]string) Chain
+		wantCalls []string
+		wantErr   bool
+	}{
+		{"First succeeds", func(calls *[]string) Chain {
+			return Chain{fakeNotifier{"dbus", nil, calls}, fakeNotifier{"bell", nil, calls}}
+		}, []string{"dbus"}, false},
+		{"Falls through in order", func(calls *[]string) Chain {
+			return Chain{fakeNotifier{"dbus", failed, calls}, fakeNotifier{"notify-send", failed, calls},
+				fakeNotifier{"bell", nil, calls}}
+		}, []string{"dbus", "notify-send", "bell"}, false},
+		{"All fail", func(calls *[]string) Chain {
+			return Chain{fakeNotifier{"dbus", failed, calls}, fakeNotifier{"bell", failed, calls}}
+		}, []string{"dbus", "bell"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			err := tt.chain(&calls).Notify(Message{Title: "Title", Body: "Body"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrNoNotifier) {
+				t.Errorf("Notify() error = %v, want ErrNoNotifier", err)
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("Notify() called %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestChainNotifyStderrOnly(t *testing.T) {
+	var calls []string
+	chain := Chain{fakeNotifier{"dbus", errors.New("failed"), &calls}, stderrNotifier{}}
+	if err := chain.Notify(Message{Title: "Title", Body: "Body"}); !errors.Is(err, ErrNoNotifier) {
+		t.Errorf("Notify() error = %v, want ErrNoNotifier when only stderr succeeded", err)
+	}
+
+	// Unless the banner is all the user asked for
+	if err := (Chain{stderrNotifier{}}).Notify(Message{Title: "Title", Body: "Body"}); err != nil {
+		t.Errorf("Notify() error = %v with stderr alone, want nil", err)
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Time completed: Focus", `"Time completed: Focus"`},
+		{`Say "hi"`, `"Say \"hi\""`},
+		{`C:\temp\u0041`, `"C:\\temp\\u0041"`},
+		{"Café ☕\tdone", "\"Café ☕\tdone\""},
+	}
+
+	for _, tt := range tests {
+		if got := appleScriptString(tt.value); got != tt.want {
+			t.Errorf("appleScriptString(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
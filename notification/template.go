@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+const defaultBodyTemplate = "Time completed: {{.Category}}"
+
+// MessageData is available to the notification templates
+type MessageData struct {
+	Title       string // Notification title from --notif or DEFAULT_NOTIFICATION
+	Category    string
+	Description string
+	Planned     time.Duration // Zero in unlimited mode
+	Elapsed     time.Duration
+	Overtime    time.Duration
+	Start       time.Time
+	End         time.Time
+	Today       time.Duration // Total of the category today, including this task
+}
+
+type Templates struct {
+	title *template.Template
+	body  *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"clock": func(t time.Time) string {
+		return t.Format("15:04")
+	},
+	"round": func(d time.Duration) time.Duration {
+		return d.Round(time.Second)
+	},
+}
+
+// NewTemplates parses the title and body templates, an empty title keeps the
+// configured notification title and an empty body uses the default message.
+func NewTemplates(title, body string) (*Templates, error) {
+	if title == "" {
+		title = "{{.Title}}"
+	}
+
+	if body == "" {
+		body = defaultBodyTemplate
+	}
+
+	titleTmpl, err := template.New("title").Funcs(templateFuncs).Parse(title)
+	if err != nil {
+		return nil, fmt.Errorf("parsing title template: %w", err)
+	}
+
+	bodyTmpl, err := template.New("body").Funcs(templateFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing body template: %w", err)
+	}
+
+	return &Templates{title: titleTmpl, body: bodyTmpl}, nil
+}
+
+// Render returns the title and body of a notification
+func (t *Templates) Render(data MessageData) (string, string, error) {
+	var title, body bytes.Buffer
+
+	if err := t.title.Execute(&title, data); err != nil {
+		return "", "", fmt.Errorf("rendering title template: %w", err)
+	}
+
+	if err := t.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("rendering body template: %w", err)
+	}
+
+	return title.String(), body.String(), nil
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplates(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	data := MessageData{
+		Title:    "Time has been finalized",
+		Category: "Focus",
+		Planned:  25 * time.Minute,
+		Elapsed:  25*time.Minute + 400*time.Millisecond,
+		Start:    start,
+		End:      start.Add(25 * time.Minute),
+		Today:    90 * time.Minute,
+	}
+
+	tests := []struct {
+		name          string
+		title         string
+		body          string
+		wantTitle     string
+		wantBody      string
+		wantErr       bool // Parsing
+		wantRenderErr bool // Rendering
+	}{
+		{"Defaults", "", "", "Time has been finalized", "Time completed: Focus", false, false},
+		{"Custom", "{{.Category}} done", "{{clock .Start}}-{{clock .End}} {{round .Elapsed}}", "Focus done",
+			"09:30-09:55 25m0s", false, false},
+		{"Total of today", "", "{{.Today}} of {{.Category}} today", "Time has been finalized",
+			"1h30m0s of Focus today", false, false},
+		{"Invalid title", "{{.Category", "", "", "", true, false},
+		{"Invalid body", "", "{{if}}", "", "", true, false},
+		{"Unknown field", "", "{{.Unknown}}", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := NewTemplates(tt.title, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			title, body, err := templates.Render(data)
+			if (err != nil) != tt.wantRenderErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantRenderErr)
+			}
+			if err != nil {
+				return
+			}
+			if title != tt.wantTitle || body != tt.wantBody {
+				t.Errorf("Render() = %q, %q, want %q, %q", title, body, tt.wantTitle, tt.wantBody)
+			}
+		})
+	}
+}
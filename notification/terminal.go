@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// terminalNotifier asks the terminal emulator to show the notification with
+// OSC escape sequences, so it reaches the local machine over SSH. The title
+// of the terminal is set with OSC 0 as well.
+type terminalNotifier struct {
+	osc string // "9" or "777"
+}
+
+func newTerminalNotifier(cfg map[string]string) Notifier {
+	osc := cfg["TERMINAL_OSC"]
+	if osc != "777" {
+		osc = "9"
+	}
+	return terminalNotifier{osc: osc}
+}
+
+func (terminalNotifier) Name() string { return "terminal" }
+
+func (n terminalNotifier) Notify(msg Message) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening terminal: %w", err)
+	}
+	defer tty.Close()
+
+	inTmux := os.Getenv("TMUX") != ""
+
+	var sequences strings.Builder
+	for _, seq := range n.sequences(msg.Title, msg.Body) {
+		if inTmux {
+			seq = tmuxPassthrough(seq)
+		}
+		sequences.WriteString(seq)
+	}
+
+	if _, err := tty.WriteString(sequences.String()); err != nil {
+		return fmt.Errorf("writing to terminal: %w", err)
+	}
+
+	// Passthrough is disabled by default in tmux, the status line message is
+	// always shown. The sequences are already written, failing here would have
+	// the next notifier show the notification twice.
+	if inTmux {
+		if err := run(exec.Command("tmux", "display-message", tmuxMessage(msg.Title, msg.Body))); err != nil {
+			log.Printf("Error showing the notification in tmux: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (n terminalNotifier) sequences(title, message string) []string {
+	title, message = sanitizeOSC(title), sanitizeOSC(message)
+
+	notify := fmt.Sprintf("\x1b]9;%s: %s\x07", title, message)
+	if n.osc == "777" {
+		notify = fmt.Sprintf("\x1b]777;notify;%s;%s\x07", title, message)
+	}
+
+	return []string{
+		notify,
+		fmt.Sprintf("\x1b]0;%s: %s\x07", title, message),
+	}
+}
+
+// tmuxMessage formats the text of display-message, escaping the # of the
+// tmux formats, e.g. #(command) would run a command
+func tmuxMessage(title, message string) string {
+	return strings.ReplaceAll(fmt.Sprintf("%s: %s", title, message), "#", "##")
+}
+
+// tmuxPassthrough wraps a sequence so tmux forwards it to the outer terminal
+func tmuxPassthrough(seq string) string {
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// sanitizeOSC removes the characters that would end the sequence early
+func sanitizeOSC(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		if r == ';' {
+			return ','
+		}
+		return r
+	}, s)
+}
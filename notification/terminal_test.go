@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTerminalSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		osc     string
+		title   string
+		message string
+		want    []string
+	}{
+		{"OSC 9", "9", "Done", "Focus", []string{"\x1b]9;Done: Focus\x07", "\x1b]0;Done: Focus\x07"}},
+		{"OSC 777", "777", "Done", "Focus", []string{"\x1b]777;notify;Done;Focus\x07", "\x1b]0;Done: Focus\x07"}},
+		{"Sanitized", "777", "a;b", "c\x07d\x1b", []string{"\x1b]777;notify;a,b;c d \x07", "\x1b]0;a,b: c d \x07"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := terminalNotifier{osc: tt.osc}.sequences(tt.title, tt.message)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sequences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxPassthrough(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  string
+		want string
+	}{
+		{"OSC", "\x1b]9;Done\x07", "\x1bPtmux;\x1b\x1b]9;Done\x07\x1b\\"},
+		{"No escape", "plain", "\x1bPtmux;plain\x1b\\"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tmuxPassthrough(tt.seq); got != tt.want {
+				t.Errorf("tmuxPassthrough() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxMessage(t *testing.T) {
+	tests := []struct {
+		title   string
+		message string
+		want    string
+	}{
+		{"Done", "Time completed: Focus", "Done: Time completed: Focus"},
+		{"Done", "Ticket #42", "Done: Ticket ##42"},
+		{"#(rm -rf ~)", "#{pane_id} ##", "##(rm -rf ~): ##{pane_id} ####"},
+	}
+
+	for _, tt := range tests {
+		if got := tmuxMessage(tt.title, tt.message); got != tt.want {
+			t.Errorf("tmuxMessage(%q, %q) = %q, want %q", tt.title, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeOSC(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"Plain", "Time completed: Focus", "Time completed: Focus"},
+		{"Control characters", "a\nb\tc\x07d\x1be\x7f", "a b c d e "},
+		{"Separator", "one;two", "one,two"},
+		{"Unicode", "Café ☕", "Café ☕"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeOSC(tt.value); got != tt.want {
+				t.Errorf("sanitizeOSC() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package notification
+
+import (
+	"fmt"
+	"log"
+	"log/syslog"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// ttyNotifier writes the message to every terminal of the user, like write(1)
+// does, so it works without a desktop session. With BROADCAST_SYSLOG=true the
+// message is also sent to journald, or syslog when journald is not running.
+type ttyNotifier struct {
+	syslog bool
+}
+
+func newTTYNotifier(cfg map[string]string) Notifier {
+	return ttyNotifier{syslog: cfg["BROADCAST_SYSLOG"] == "true"}
+}
+
+func (ttyNotifier) Name() string { return "tty" }
+
+func (n ttyNotifier) Notify(msg Message) error {
+	// The log is an extra, the terminals are still written when it fails
+	if n.syslog {
+		if err := sendSyslog(msg); err != nil {
+			log.Printf("Error sending to syslog: %s", err)
+		}
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return fmt.Errorf("getting current user: %w", err)
+	}
+
+	ttys, err := userTTYs(current.Username)
+	if err != nil {
+		return err
+	}
+
+	text := ttyText(msg)
+
+	written := 0
+	for _, tty := range ttys {
+		file, err := os.OpenFile(filepath.Join("/dev", tty), os.O_WRONLY, 0)
+		if err != nil {
+			continue
+		}
+
+		if _, err := file.WriteString(text); err == nil {
+			written++
+		}
+		file.Close()
+	}
+
+	if written == 0 {
+		return fmt.Errorf("no writable terminal for %s", current.Username)
+	}
+
+	return nil
+}
+
+// ttyText rings the bell and frames the message, terminals written directly
+// need carriage returns
+func ttyText(msg Message) string {
+	return "\r\n\a" + strings.ReplaceAll(banner(msg), "\n", "\r\n")
+}
+
+// userTTYs lists the terminals where the user is logged in
+func userTTYs(username string) ([]string, error) {
+	output, err := exec.Command("who").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing logged in users: %w", err)
+	}
+
+	var ttys []string
+	for line := range strings.SplitSeq(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == username {
+			ttys = append(ttys, fields[1])
+		}
+	}
+
+	return ttys, nil
+}
+
+// sendSyslog logs the message with the task details as structured fields in
+// journald, or as key=value pairs in syslog.
+func sendSyslog(msg Message) error {
+	text := fmt.Sprintf("%s: %s", msg.Title, msg.Body)
+	durationMs := strconv.FormatInt(msg.Elapsed.Milliseconds(), 10)
+
+	if _, err := os.Stat(journalSocket); err == nil {
+		return sendJournal(map[string]string{
+			"MESSAGE":           text,
+			"PRIORITY":          "5",
+			"SYSLOG_IDENTIFIER": "jn",
+			"JN_CATEGORY":       msg.Category,
+			"JN_DURATION_MS":    durationMs,
+		})
+	}
+
+	writer, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_USER, "jn")
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return writer.Notice(fmt.Sprintf("%s category=%q duration_ms=%s", text, msg.Category, durationMs))
+}
+
+// sendJournal writes an entry with the journald native protocol
+func sendJournal(fields map[string]string) error {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(journalEntry(fields)))
+	return err
+}
+
+// journalEntry formats the fields sorted by name, values are single line in
+// the simple form of the protocol
+func journalEntry(fields map[string]string) string {
+	var entry strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		fmt.Fprintf(&entry, "%s=%s\n", key, strings.ReplaceAll(fields[key], "\n", " "))
+	}
+	return entry.String()
+}
@@ -0,0 +1,60 @@
+package notification
+
+import "testing"
+
+func TestBanner(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"Longer body", Message{Title: "Done", Body: "Time completed: Focus"},
+			"=========================\n  Done\n  Time completed: Focus\n=========================\n"},
+		{"Longer title", Message{Title: "Time has been finalized", Body: "Focus"},
+			"===========================\n  Time has been finalized\n  Focus\n===========================\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := banner(tt.msg); got != tt.want {
+				t.Errorf("banner() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTYText(t *testing.T) {
+	got := ttyText(Message{Title: "Done", Body: "Focus"})
+	want := "\r\n\a=========\r\n  Done\r\n  Focus\r\n=========\r\n"
+	if got != want {
+		t.Errorf("ttyText() = %q, want %q", got, want)
+	}
+}
+
+func TestJournalEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   string
+	}{
+		{"Sorted", map[string]string{
+			"SYSLOG_IDENTIFIER": "jn",
+			"MESSAGE":           "Done: Focus",
+			"PRIORITY":          "5",
+			"JN_CATEGORY":       "Focus",
+		}, "JN_CATEGORY=Focus\nMESSAGE=Done: Focus\nPRIORITY=5\nSYSLOG_IDENTIFIER=jn\n"},
+		{"Single line values", map[string]string{"MESSAGE": "Done:\nline two\n"}, "MESSAGE=Done: line two \n"},
+		{"Empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Maps are iterated in random order, a few runs catch an unsorted output
+			for range 5 {
+				if got := journalEntry(tt.fields); got != tt.want {
+					t.Fatalf("journalEntry() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	glyphHeight = 5
+	maxBigScale = 4
+)
+
+// Block digits of the big clock, every row of a glyph has the same width.
+// The blocks are drawn with the Block of the theme.
+var bigGlyphs = map[rune][glyphHeight]string{
+	'0': {"█████", "█   █", "█   █", "█   █", "█████"},
+	'1': {"  █  ", " ██  ", "  █  ", "  █  ", " ███ "},
+	'2': {"█████", "    █", "█████", "█    ", "█████"},
+	'3': {"█████", "    █", " ████", "    █", "█████"},
+	'4': {"█   █", "█   █", "█████", "    █", "    █"},
+	'5': {"█████", "█    ", "█████", "    █", "█████"},
+	'6': {"█████", "█    ", "█████", "█   █", "█████"},
+	'7': {"█████", "    █", "   █ ", "  █  ", "  █  "},
+	'8': {"█████", "█   █", "█████", "█   █", "█████"},
+	'9': {"█████", "█   █", "█████", "    █", "█████"},
+	':': {"   ", " █ ", "   ", " █ ", "   "},
+}
+
+// BigClock draws the remaining time in large block digits centered on the
+// screen, an alternative to ProgressBar with the same arguments and result.
+// It falls back to ProgressBar when the output is not a terminal.
+func BigClock(closeSignal chan bool, timer *Timer, theme *Theme, label string, keyboard *Keyboard) bool {
+	if !IsTerminal(os.Stdout) {
+		return ProgressBar(closeSignal, timer, theme, label, keyboard)
+	}
+
+	if timer.State().Completed() {
+		return true
+	}
+
+	if theme == nil {
+		theme = DefaultTheme()
+	}
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	// The final state is printed on the main screen with its width
+	line := NewStatusLine(os.Stdout)
+
+	// Logs would be drawn over the clock, they are held until the main
+	// screen is restored
+	var logs bytes.Buffer
+	logWriter := log.Writer()
+	log.SetOutput(&logs)
+
+	// Alternate screen and hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	finish := func(state TimerState) {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		log.SetOutput(logWriter)
+		logWriter.Write(logs.Bytes())
+		line.Done(theme.Render(state, label, line.Width()))
+	}
+
+	drawBigClock(timer.State(), theme, label, keyboard)
+
+	for {
+		select {
+		case <-closeSignal:
+			finish(timer.State())
+			return false
+		case <-resize:
+			fmt.Print("\x1b[2J")
+		case <-keyboard.Changed():
+		case <-ticker.C:
+		}
+
+		state := timer.State()
+		if state.Completed() {
+			finish(state)
+			return true
+		}
+		drawBigClock(state, theme, label, keyboard)
+	}
+}
+
+func drawBigClock(state TimerState, theme *Theme, label string, keyboard *Keyboard) {
+	width, height, err := TerminalSize(os.Stdout)
+	if err != nil {
+		width, height = defaultWidth, 24
+	}
+
+	// Leave room for the label, the bar and the status around the digits
+	digits := bigDigits(formatBigClock(state.Remaining()), theme.Block)
+	digitsWidth := len([]rune(digits[0]))
+	scale := max(1, min(maxBigScale, (width-4)/max(1, digitsWidth), (height-6)/glyphHeight))
+	digits = scaleRows(digits, scale)
+
+	status := fmt.Sprintf("%5.1f%%", state.Progress()*100)
+	if state.Paused {
+		status += "  paused"
+	} else {
+		status += "  ETA " + time.Now().Add(state.Remaining()).Format("15:04")
+	}
+	status = keyboard.Status(status)
+
+	barWidth := min(len([]rune(digits[0])), width-4)
+	color := theme.barColor(label, state.Progress())
+
+	var lines []string
+	lines = append(lines, label, "")
+	for _, row := range digits {
+		lines = append(lines, theme.paint(row, color))
+	}
+	lines = append(lines, "")
+	if barWidth >= minBarWidth {
+		lines = append(lines, theme.paint(theme.Bar(state.Progress(), barWidth), color))
+	}
+	lines = append(lines, status)
+
+	top := max(0, (height-len(lines))/2)
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	for range top {
+		screen.WriteString("\x1b[K\r\n")
+	}
+	for i, line := range lines {
+		line = fitWidth(line, width)
+		screen.WriteString(strings.Repeat(" ", max(0, (width-visibleLen(line))/2)))
+		screen.WriteString(line)
+		screen.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			screen.WriteString("\r\n")
+		}
+	}
+	screen.WriteString("\x1b[J")
+	fmt.Print(screen.String())
+}
+
+// formatBigClock formats a duration as mm:ss, or h:mm:ss from one hour on
+func formatBigClock(d time.Duration) string {
+	// Round up, so the clock shows 00:00 only when the time is over
+	d = (d + time.Second - 1).Truncate(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// bigDigits renders text with the glyphs drawn with block, one space between
+// them
+func bigDigits(text, block string) []string {
+	rows := make([]string, glyphHeight)
+	for i, r := range text {
+		glyph, ok := bigGlyphs[r]
+		if !ok {
+			continue
+		}
+		for row := range rows {
+			if i > 0 {
+				rows[row] += " "
+			}
+			rows[row] += strings.ReplaceAll(glyph[row], "█", block)
+		}
+	}
+	return rows
+}
+
+// scaleRows enlarges the rows repeating every cell horizontally and every
+// row vertically
+func scaleRows(rows []string, scale int) []string {
+	if scale <= 1 {
+		return rows
+	}
+
+	var scaled []string
+	for _, row := range rows {
+		var wide strings.Builder
+		for _, r := range row {
+			wide.WriteString(strings.Repeat(string(r), scale))
+		}
+		for range scale {
+			scaled = append(scaled, wide.String())
+		}
+	}
+	return scaled
+}
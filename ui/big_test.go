@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFormatBigClock(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      string
+	}{
+		{0, "00:00"},
+		{time.Millisecond, "00:01"},
+		{25 * time.Minute, "25:00"},
+		{25*time.Minute - 1500*time.Millisecond, "24:59"},
+		{59*time.Minute + 59*time.Second + time.Millisecond, "1:00:00"},
+		{12*time.Hour + 3*time.Minute + 4*time.Second, "12:03:04"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBigClock(tt.remaining); got != tt.want {
+			t.Errorf("formatBigClock(%s) = %q, want %q", tt.remaining, got, tt.want)
+		}
+	}
+}
+
+func TestBigDigits(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		block string
+		want  []string
+	}{
+		{"Default block", "1:0", "█", []string{
+			"  █       █████",
+			" ██    █  █   █",
+			"  █       █   █",
+			"  █    █  █   █",
+			" ███      █████",
+		}},
+		{"ASCII block", "7", "#", []string{
+			"#####",
+			"    #",
+			"   # ",
+			"  #  ",
+			"  #  ",
+		}},
+		{"Unknown runes skipped", "x", "#", []string{"", "", "", "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bigDigits(tt.text, tt.block); !slices.Equal(got, tt.want) {
+				t.Errorf("bigDigits(%q, %q) = %q, want %q", tt.text, tt.block, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type DashboardTimer struct {
+	Category    string
+	Description string
+	State       TimerState
+}
+
+type DashboardEntry struct {
+	Category    string
+	Description string
+	Start       time.Time
+	End         time.Time // Zero while the task is not finished
+}
+
+type DashboardTotal struct {
+	Category string
+	Total    time.Duration
+	Sessions int
+}
+
+type DashboardData struct {
+	Timers  []DashboardTimer
+	Totals  []DashboardTotal // Today
+	History []DashboardEntry // Most recent first
+}
+
+// DashboardSource provides the data shown by the dashboard and runs the
+// actions requested from the keyboard
+type DashboardSource interface {
+	Load() (DashboardData, error)
+	Start(category, duration string) error
+	Stop(category string) error
+	Pause(category string) error
+	Resume(category string) error
+	Extend(category string, d time.Duration) error
+}
+
+const extendStep = 5 * time.Minute
+
+const (
+	paneTimers = iota
+	paneHistory
+)
+
+type dashboard struct {
+	source   DashboardSource
+	theme    *Theme
+	data     DashboardData
+	pane     int
+	selected [2]int
+	prompt   *string         // Input of the start prompt, nil when closed
+	detail   *DashboardEntry // Entry opened from the history, nil when closed
+	message  string
+}
+
+// Dashboard runs a full screen view of all the timers until q is pressed, the
+// bars are drawn with the theme
+func Dashboard(source DashboardSource, theme *Theme) error {
+	restore, err := MakeRaw()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// Alternate screen and hidden cursor, restored on exit
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	// Stops the key reader with the dashboard
+	done := make(chan struct{})
+	defer close(done)
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys, done)
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	d := &dashboard{source: source, theme: theme}
+	d.reload()
+	d.render()
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || !d.handleKey(key) {
+				return nil
+			}
+		case <-resize:
+		case <-ticker.C:
+			d.reload()
+		}
+		d.render()
+	}
+}
+
+// readKeys sends every key pressed on in, escape sequences are sent whole,
+// until done is closed. A read in progress ends with the next key.
+func readKeys(in io.Reader, keys chan<- string, done <-chan struct{}) {
+	defer close(keys)
+
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+
+		select {
+		case keys <- string(buf[:n]):
+		case <-done:
+			return
+		}
+	}
+}
+
+func (d *dashboard) reload() {
+	data, err := d.source.Load()
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+	d.data = data
+
+	for pane, size := range []int{len(data.Timers), len(data.History)} {
+		d.selected[pane] = max(0, min(d.selected[pane], size-1))
+	}
+}
+
+// handleKey runs the action of a key, it returns false to quit
+func (d *dashboard) handleKey(key string) bool {
+	if d.prompt != nil {
+		d.handlePrompt(key)
+		return true
+	}
+
+	if d.detail != nil {
+		if key == "\r" || key == "\x1b" || key == "q" {
+			d.detail = nil
+		}
+		return true
+	}
+
+	d.message = ""
+
+	switch key {
+	case "q", "\x03":
+		return false
+	case "\t":
+		d.pane = 1 - d.pane
+	case "k", "\x1b[A":
+		d.selected[d.pane] = max(0, d.selected[d.pane]-1)
+	case "j", "\x1b[B":
+		size := len(d.data.Timers)
+		if d.pane == paneHistory {
+			size = len(d.data.History)
+		}
+		d.selected[d.pane] = max(0, min(d.selected[d.pane]+1, size-1))
+	case "s":
+		input := ""
+		d.prompt = &input
+	case "\r":
+		if d.pane == paneHistory && len(d.data.History) > 0 {
+			d.detail = &d.data.History[d.selected[paneHistory]]
+		}
+	case "x", "p", "+":
+		timer, ok := d.selectedTimer()
+		if !ok {
+			d.message = "No timer selected"
+			return true
+		}
+		d.runTimerAction(key, timer)
+	}
+
+	return true
+}
+
+func (d *dashboard) selectedTimer() (DashboardTimer, bool) {
+	if d.pane != paneTimers || len(d.data.Timers) == 0 {
+		return DashboardTimer{}, false
+	}
+	return d.data.Timers[d.selected[paneTimers]], true
+}
+
+func (d *dashboard) runTimerAction(key string, timer DashboardTimer) {
+	var err error
+
+	switch key {
+	case "x":
+		err = d.source.Stop(timer.Category)
+		d.message = fmt.Sprintf("%s stopped", timer.Category)
+	case "p":
+		if timer.State.Paused {
+			err = d.source.Resume(timer.Category)
+			d.message = fmt.Sprintf("%s resumed", timer.Category)
+		} else {
+			err = d.source.Pause(timer.Category)
+			d.message = fmt.Sprintf("%s paused", timer.Category)
+		}
+	case "+":
+		err = d.source.Extend(timer.Category, extendStep)
+		d.message = fmt.Sprintf("%s extended %s", timer.Category, extendStep)
+	}
+
+	if err != nil {
+		d.message = err.Error()
+	}
+}
+
+func (d *dashboard) handlePrompt(key string) {
+	switch key {
+	case "\x1b", "\x03":
+		d.prompt = nil
+	case "\x7f", "\b":
+		if runes := []rune(*d.prompt); len(runes) > 0 {
+			*d.prompt = string(runes[:len(runes)-1])
+		}
+	case "\r":
+		fields := strings.Fields(*d.prompt)
+		d.prompt = nil
+
+		if len(fields) != 2 {
+			d.message = "Expected a category and a time, e.g. Focus 25m"
+			return
+		}
+
+		if err := d.source.Start(fields[0], fields[1]); err != nil {
+			d.message = err.Error()
+			return
+		}
+		d.message = fmt.Sprintf("%s started", fields[0])
+	default:
+		if !strings.HasPrefix(key, "\x1b") {
+			*d.prompt += key
+		}
+	}
+}
+
+func (d *dashboard) render() {
+	width, height, err := TerminalSize(os.Stdout)
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	section := func(title string, focused bool) {
+		marker := " "
+		if focused {
+			marker = d.theme.Marker
+		}
+		header := fmt.Sprintf("%s %s ", marker, title)
+		add("%s%s", header, strings.Repeat(d.theme.Rule, max(0, width-len([]rune(header)))))
+	}
+
+	add(" jn dashboard%s", padLeft(time.Now().Format("15:04:05"), width-13))
+	add("")
+
+	section("Running", d.pane == paneTimers)
+	if len(d.data.Timers) == 0 {
+		add("   No running timers")
+	}
+	for i, timer := range d.data.Timers {
+		add("%s", d.timerLine(timer, i == d.selected[paneTimers] && d.pane == paneTimers, width))
+	}
+	add("")
+
+	section("Today", false)
+	if len(d.data.Totals) == 0 {
+		add("   Nothing logged today")
+	}
+	for _, total := range d.data.Totals {
+		add("   %-16s %9s  %d sessions", truncate(total.Category, 16), FormatDuration(total.Total), total.Sessions)
+	}
+	add("")
+
+	section("Recent", d.pane == paneHistory)
+	// Keep room for the footer
+	room := max(0, height-len(lines)-3)
+	for i, entry := range d.data.History {
+		if i >= room {
+			break
+		}
+		cursor := "  "
+		if i == d.selected[paneHistory] && d.pane == paneHistory {
+			cursor = "> "
+		}
+		end, duration := "running", "-"
+		if !entry.End.IsZero() {
+			end = entry.End.Format("15:04")
+			duration = FormatDuration(entry.End.Sub(entry.Start))
+		}
+		line := fmt.Sprintf(" %s%s %s-%-7s %-16s %9s  %s", cursor, entry.Start.Format("01/02"),
+			entry.Start.Format("15:04"), end, truncate(entry.Category, 16), duration, entry.Description)
+		add("%s", truncate(line, width))
+	}
+
+	if d.detail != nil {
+		lines = d.detailLines(width)
+	}
+
+	footer := " s start  x stop  p pause  + extend  enter open  tab switch  q quit"
+	switch {
+	case d.prompt != nil:
+		footer = " Category and time (e.g. Focus 25m): " + *d.prompt + d.theme.Cursor
+	case d.message != "":
+		footer = " " + d.message
+	}
+
+	for len(lines) < height-1 {
+		add("")
+	}
+	lines = append(lines[:height-1], truncate(footer, width))
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+	for i, line := range lines {
+		screen.WriteString(fitWidth(line, width))
+		screen.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			screen.WriteString("\r\n")
+		}
+	}
+	fmt.Print(screen.String())
+}
+
+func (d *dashboard) timerLine(timer DashboardTimer, selected bool, width int) string {
+	cursor := "  "
+	if selected {
+		cursor = "> "
+	}
+
+	state := timer.State
+	status := ""
+	if state.Paused {
+		status = " paused"
+	}
+
+	prefix := fmt.Sprintf(" %s%-16s ", cursor, truncate(timer.Category, 16))
+
+	if state.Planned == 0 {
+		return truncate(fmt.Sprintf("%selapsed %s%s  %s", prefix, FormatClock(state.Elapsed), status,
+			timer.Description), width)
+	}
+
+	suffix := fmt.Sprintf(" %5.1f%% %s left%s", state.Progress()*100, FormatClock(state.Remaining()), status)
+	capsLen := len([]rune(d.theme.Left)) + len([]rune(d.theme.Right))
+	barWidth := max(minBarWidth, min(maxBarWidth, width-len([]rune(prefix))-len(suffix)-capsLen))
+	bar := d.theme.paint(d.theme.Bar(state.Progress(), barWidth), d.theme.barColor(timer.Category, state.Progress()))
+
+	return fitWidth(prefix+d.theme.Left+bar+d.theme.Right+suffix, width)
+}
+
+func (d *dashboard) detailLines(width int) []string {
+	entry := d.detail
+	end, duration := "running", "-"
+	if !entry.End.IsZero() {
+		end = entry.End.Format("2006-01-02 15:04:05")
+		duration = FormatDuration(entry.End.Sub(entry.Start))
+	}
+
+	title := " Log entry "
+	return []string{
+		title + strings.Repeat(d.theme.Rule, max(0, width-len(title))),
+		"",
+		"   Category:    " + entry.Category,
+		"   Description: " + entry.Description,
+		"   Start:       " + entry.Start.Format("2006-01-02 15:04:05"),
+		"   End:         " + end,
+		"   Duration:    " + duration,
+		"",
+		"   Press enter or esc to close",
+	}
+}
+
+// FormatClock formats a duration as hh:mm:ss
+func FormatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// FormatDuration formats a duration rounded to minutes, e.g. 45m or 2h05m
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(0, width)])
+}
+
+func padLeft(s string, width int) string {
+	return fmt.Sprintf("%*s", max(width, len(s)), s)
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeSource records the timers started from the dashboard
+type fakeSource struct {
+	started []string
+}
+
+func (f *fakeSource) Load() (DashboardData, error) { return DashboardData{}, nil }
+
+func (f *fakeSource) Start(category, duration string) error {
+	f.started = append(f.started, category+" "+duration)
+	return nil
+}
+
+func (f *fakeSource) Stop(string) error                  { return nil }
+func (f *fakeSource) Pause(string) error                 { return nil }
+func (f *fakeSource) Resume(string) error                { return nil }
+func (f *fakeSource) Extend(string, time.Duration) error { return nil }
+
+func TestDashboardPrompt(t *testing.T) {
+	source := &fakeSource{}
+	input := ""
+	d := &dashboard{source: source, prompt: &input}
+
+	for _, key := range []string{"R", "é", "s", "u", "m", "é", "\x7f", "é", " ", "2", "5", "m", "\x1b[A"} {
+		d.handlePrompt(key)
+	}
+	if *d.prompt != "Résumé 25m" {
+		t.Fatalf("prompt = %q, want %q", *d.prompt, "Résumé 25m")
+	}
+
+	// Backspace removes whole runes, the text stays valid UTF-8
+	for range 5 {
+		d.handlePrompt("\x7f")
+	}
+	if *d.prompt != "Résum" {
+		t.Fatalf("prompt = %q after the backspaces, want %q", *d.prompt, "Résum")
+	}
+
+	d.handlePrompt("é")
+	d.handlePrompt(" ")
+	d.handlePrompt("5")
+	d.handlePrompt("m")
+	d.handlePrompt("\r")
+	if d.prompt != nil || len(source.started) != 1 || source.started[0] != "Résumé 5m" {
+		t.Errorf("started %q, want Résumé 5m and the prompt closed", source.started)
+	}
+}
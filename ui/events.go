@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Lifecycle events of the JSON output
+const (
+	EventScheduled = "scheduled"
+	EventTick      = "tick"
+	EventWarning   = "warning"
+	EventNag       = "nag"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	EventExtended  = "extended"
+	EventLap       = "lap"
+	EventFinished  = "finished"
+	EventKilled    = "killed"
+	EventLogged    = "logged"
+	EventError     = "error"
+)
+
+// Event is written as a JSON line for each lifecycle event of a task
+type Event struct {
+	Event     string `json:"event"`
+	TaskID    string `json:"task_id"`
+	Category  string `json:"category"`
+	Time      int64  `json:"time_ms"`
+	InitTime  int64  `json:"init_time_ms"`
+	Elapsed   int64  `json:"elapsed_ms"`
+	Planned   int64  `json:"planned_ms"`   // Zero in unlimited mode
+	Remaining int64  `json:"remaining_ms"` // Zero in unlimited mode
+	Paused    bool   `json:"paused"`
+	Message   string `json:"message,omitempty"`
+}
+
+// EventWriter writes the events of a task, it is safe for concurrent use
+type EventWriter struct {
+	mu       sync.Mutex
+	encoder  *json.Encoder
+	timer    *Timer
+	taskID   string
+	category string
+	initTime int64
+}
+
+func NewEventWriter(out io.Writer, timer *Timer, taskID, category string, initTime int64) *EventWriter {
+	return &EventWriter{
+		encoder:  json.NewEncoder(out),
+		timer:    timer,
+		taskID:   taskID,
+		category: category,
+		initTime: initTime,
+	}
+}
+
+// Emit writes an event with the current state of the timer
+func (w *EventWriter) Emit(event, message string) {
+	state := w.timer.State()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.encoder.Encode(Event{
+		Event:     event,
+		TaskID:    w.taskID,
+		Category:  w.category,
+		Time:      time.Now().UnixMilli(),
+		InitTime:  w.initTime,
+		Elapsed:   state.Elapsed.Milliseconds(),
+		Planned:   state.Planned.Milliseconds(),
+		Remaining: state.Remaining().Milliseconds(),
+		Paused:    state.Paused,
+		Message:   message,
+	})
+}
+
+// EventTicks emits a tick every second instead of drawing the progress, it
+// returns true when the time is completed and false when it was interrupted
+// by closeSignal.
+func EventTicks(closeSignal chan bool, timer *Timer, events *EventWriter) bool {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closeSignal:
+			return false
+		case <-ticker.C:
+			if timer.State().Completed() {
+				return true
+			}
+			events.Emit(EventTick, "")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// decodeEvents decodes every line of the output as an event
+func decodeEvents(t *testing.T, out *bytes.Buffer) []Event {
+	t.Helper()
+
+	var events []Event
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not a JSON event: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestEventWriter(t *testing.T) {
+	init := time.Now().Add(-time.Minute).UnixMilli()
+	timer := NewTimer(init, init+10*time.Minute.Milliseconds())
+
+	var out bytes.Buffer
+	writer := NewEventWriter(&out, timer, "task-1", "Focus", init)
+	writer.Emit(EventScheduled, "10m")
+	timer.Pause()
+	writer.Emit(EventPaused, "")
+	writer.Emit(EventLap, "a \"quoted\"\nnote")
+
+	events := decodeEvents(t, &out)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	first := events[0]
+	if first.Event != EventScheduled || first.TaskID != "task-1" || first.Category != "Focus" ||
+		first.InitTime != init || first.Message != "10m" || first.Planned != 10*time.Minute.Milliseconds() {
+		t.Errorf("got %+v, want the scheduled event of the task", first)
+	}
+	if first.Elapsed < time.Minute.Milliseconds() || first.Remaining != first.Planned-first.Elapsed {
+		t.Errorf("got elapsed %d and remaining %d, want them from the timer", first.Elapsed, first.Remaining)
+	}
+
+	if !events[1].Paused || events[1].Message != "" {
+		t.Errorf("got %+v, want a paused event without message", events[1])
+	}
+	if events[2].Message != "a \"quoted\"\nnote" {
+		t.Errorf("got message %q, want the note unchanged", events[2].Message)
+	}
+}
+
+func TestEventTicks(t *testing.T) {
+	timer := NewTimer(time.Now().UnixMilli(), 0)
+
+	var out bytes.Buffer
+	writer := NewEventWriter(&out, timer, "task-1", "Read", time.Now().UnixMilli())
+
+	closeSignal := make(chan bool)
+	result := make(chan bool)
+	go func() {
+		result <- EventTicks(closeSignal, timer, writer)
+	}()
+
+	time.Sleep(1500 * time.Millisecond)
+	closeSignal <- true
+	if completed := <-result; completed {
+		t.Error("EventTicks() = true, want false when closed")
+	}
+
+	events := decodeEvents(t, &out)
+	if len(events) != 1 || events[0].Event != EventTick || events[0].Planned != 0 {
+		t.Errorf("got %+v, want a tick of an unlimited task", events)
+	}
+}
+
+func TestEventTicksCompleted(t *testing.T) {
+	init := time.Now().Add(-time.Minute).UnixMilli()
+	timer := NewTimer(init, init+1)
+
+	var out bytes.Buffer
+	if completed := EventTicks(make(chan bool), timer, NewEventWriter(&out, timer, "task-1", "Focus", init)); !completed {
+		t.Error("EventTicks() = false, want true for a completed timer")
+	}
+	if out.Len() != 0 {
+		t.Errorf("got output %q, want no tick after the deadline", out.String())
+	}
+}
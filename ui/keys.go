@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Keyboard reads single keys from the terminal while a timer runs. Keys
+// typed while a prompt is open edit it instead of being sent.
+type Keyboard struct {
+	keys    chan string
+	changed chan struct{}
+	restore func() error
+	cursor  string
+
+	mu     sync.Mutex
+	prompt *prompt // Nil when closed
+}
+
+type prompt struct {
+	label string
+	text  string
+	done  func(string)
+}
+
+// NewKeyboard puts the terminal of stdin in cbreak mode: keys are read one at
+// a time without echo, while Ctrl-C and the output are not affected. Close
+// restores the previous settings. Prompts end with the cursor of the theme.
+func NewKeyboard(theme *Theme) (*Keyboard, error) {
+	restore, err := MakeCbreak()
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyboard{
+		keys:    make(chan string),
+		changed: make(chan struct{}, 1),
+		restore: restore,
+		cursor:  theme.Cursor,
+	}
+	go k.read(os.Stdin)
+
+	return k, nil
+}
+
+// read edits the prompt with the keys pressed on in, or sends them. The
+// keyboard reads until the process exits.
+func (k *Keyboard) read(in io.Reader) {
+	raw := make(chan string)
+	go readKeys(in, raw, nil)
+
+	for chunk := range raw {
+		// Keys typed quickly arrive together, escape sequences are kept whole
+		keys := []string{chunk}
+		if !strings.HasPrefix(chunk, "\x1b") {
+			keys = strings.Split(chunk, "")
+		}
+
+		for _, key := range keys {
+			if !k.edit(key) {
+				k.keys <- key
+			}
+		}
+	}
+	close(k.keys)
+}
+
+// Keys returns the keys pressed outside of a prompt
+func (k *Keyboard) Keys() <-chan string {
+	return k.keys
+}
+
+// Changed receives a value when the prompt must be redrawn, a nil keyboard
+// never does
+func (k *Keyboard) Changed() <-chan struct{} {
+	if k == nil {
+		return nil
+	}
+	return k.changed
+}
+
+// Prompt opens a prompt with the label, done is called with the text typed
+// when enter is pressed. Escape closes it without calling done.
+func (k *Keyboard) Prompt(label string, done func(string)) {
+	k.mu.Lock()
+	k.prompt = &prompt{label: label, done: done}
+	k.mu.Unlock()
+	k.notify()
+}
+
+// Line returns the prompt as shown in the status line, false when there is
+// no prompt open
+func (k *Keyboard) Line() (string, bool) {
+	if k == nil {
+		return "", false
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.prompt == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s: %s%s", k.prompt.label, k.prompt.text, k.cursor), true
+}
+
+// edit applies a key to the open prompt, it returns false when there is none
+func (k *Keyboard) edit(key string) bool {
+	k.mu.Lock()
+	p := k.prompt
+	if p == nil {
+		k.mu.Unlock()
+		return false
+	}
+
+	var done func(string)
+	switch key {
+	case "\x1b":
+		k.prompt = nil
+	case "\x7f", "\b":
+		if runes := []rune(p.text); len(runes) > 0 {
+			p.text = string(runes[:len(runes)-1])
+		}
+	case "\r", "\n":
+		k.prompt = nil
+		done = p.done
+	default:
+		if !strings.HasPrefix(key, "\x1b") {
+			p.text += key
+		}
+	}
+	k.mu.Unlock()
+
+	if done != nil {
+		done(strings.TrimSpace(p.text))
+	}
+	k.notify()
+	return true
+}
+
+func (k *Keyboard) notify() {
+	select {
+	case k.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Close restores the terminal settings, it is safe to call more than once
+func (k *Keyboard) Close() error {
+	if k == nil || k.restore == nil {
+		return nil
+	}
+
+	err := k.restore()
+	k.restore = nil
+	return err
+}
+
+// Status returns the prompt when one is open, text otherwise
+func (k *Keyboard) Status(text string) string {
+	if line, ok := k.Line(); ok {
+		return line
+	}
+	return text
+}
+
+// IsKeyboardAvailable reports whether keys can be read from stdin, it must be
+// a terminal in the foreground
+func IsKeyboardAvailable() bool {
+	return IsTerminal(os.Stdin) && IsForeground(os.Stdin)
+}
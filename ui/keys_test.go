@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"io"
+	"testing"
+)
+
+// chunkReader returns one chunk per Read, like a terminal returns the keys
+// typed between two reads
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func newTestKeyboard() *Keyboard {
+	return &Keyboard{
+		keys:    make(chan string),
+		changed: make(chan struct{}, 1),
+		cursor:  "_",
+	}
+}
+
+func TestKeyboardEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		wantLine string // Empty when the prompt is closed
+		wantDone []string
+	}{
+		{"Typing", []string{"C", "a", "f", "é"}, "Lap: Café_", nil},
+		{"Backspace", []string{"é", "t", "é", "\x7f", "\b"}, "Lap: é_", nil},
+		{"Backspace empty", []string{"\x7f"}, "Lap: _", nil},
+		{"Escape sequence ignored", []string{"a", "\x1b[A", "b"}, "Lap: ab_", nil},
+		{"Escape", []string{"a", "\x1b"}, "", nil},
+		{"Enter", []string{" ", "n", "o", "t", "e", " ", "\r"}, "", []string{"note"}},
+		{"Newline", []string{"x", "\n"}, "", []string{"x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKeyboard()
+			var done []string
+			k.Prompt("Lap", func(text string) { done = append(done, text) })
+
+			for _, key := range tt.keys {
+				if !k.edit(key) {
+					t.Fatalf("edit(%q) = false, want the key taken by the prompt", key)
+				}
+			}
+
+			line, ok := k.Line()
+			if ok != (tt.wantLine != "") || line != tt.wantLine {
+				t.Errorf("Line() = %q, %v, want %q", line, ok, tt.wantLine)
+			}
+			if len(done) != len(tt.wantDone) || (len(done) > 0 && done[0] != tt.wantDone[0]) {
+				t.Errorf("done called with %q, want %q", done, tt.wantDone)
+			}
+
+			// A closed prompt leaves the keys to the caller
+			if tt.wantLine == "" && k.edit("z") {
+				t.Error("edit() = true with the prompt closed, want false")
+			}
+		})
+	}
+}
+
+func TestKeyboardRead(t *testing.T) {
+	k := newTestKeyboard()
+	texts := make(chan string, 1)
+	k.Prompt("Lap", func(text string) { texts <- text })
+
+	// The keys of a chunk are split, escape sequences are kept whole
+	go k.read(&chunkReader{chunks: []string{"ré", "s", "\x7f", "\x1b[D", "\rpq", "\x1b[A"}})
+
+	var keys []string
+	for key := range k.Keys() {
+		keys = append(keys, key)
+	}
+
+	if text := <-texts; text != "ré" {
+		t.Errorf("prompt text = %q, want %q", text, "ré")
+	}
+	if len(keys) != 3 || keys[0] != "p" || keys[1] != "q" || keys[2] != "\x1b[A" {
+		t.Errorf("Keys() sent %q, want the keys typed after the prompt", keys)
+	}
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// plainInterval is how often the status is printed when the output is not a
+// terminal, e.g. a pipe or a log file
+const plainInterval = 30 * time.Second
+
+const defaultWidth = 80
+
+// StatusLine redraws a single line in place when the output is a terminal,
+// following its width. Otherwise the status is printed as a plain line
+// periodically.
+type StatusLine struct {
+	out     *os.File
+	tty     bool
+	width   int
+	lastLen int
+	last    string // Text shown, redrawn after Print
+	printed time.Time
+	resize  chan os.Signal
+}
+
+func NewStatusLine(out *os.File) *StatusLine {
+	l := &StatusLine{out: out, tty: IsTerminal(out), width: defaultWidth}
+
+	if l.tty {
+		l.resize = make(chan os.Signal, 1)
+		signal.Notify(l.resize, syscall.SIGWINCH)
+		l.updateWidth()
+	}
+
+	return l
+}
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (l *StatusLine) IsTerminal() bool {
+	return l.tty
+}
+
+// Width returns the columns available for the line, zero in plain mode so
+// only text is printed
+func (l *StatusLine) Width() int {
+	if !l.tty {
+		return 0
+	}
+
+	if l.resize != nil {
+		select {
+		case <-l.resize:
+			l.updateWidth()
+			l.clear()
+		default:
+		}
+	}
+	return l.width
+}
+
+func (l *StatusLine) updateWidth() {
+	if cols, _, err := TerminalSize(l.out); err == nil {
+		l.width = cols
+	}
+}
+
+// clear erases the current line, and the rows it wrapped into when the
+// terminal became narrower than the text
+func (l *StatusLine) clear() {
+	rows := 0
+	if l.width > 0 && l.lastLen > l.width {
+		rows = (l.lastLen - 1) / l.width
+	}
+
+	fmt.Fprint(l.out, "\r")
+	if rows > 0 {
+		fmt.Fprintf(l.out, "\x1b[%dA", rows)
+	}
+	fmt.Fprint(l.out, "\x1b[J")
+}
+
+// Update shows text, in plain mode only when the interval passed since the
+// last print
+func (l *StatusLine) Update(text string) {
+	if !l.tty {
+		if time.Since(l.printed) >= plainInterval {
+			fmt.Fprintln(l.out, text)
+			l.printed = time.Now()
+		}
+		return
+	}
+
+	l.last = text
+	text = fitWidth(text, l.Width())
+	fmt.Fprintf(l.out, "\r%s\x1b[K", text)
+	l.lastLen = visibleLen(text)
+}
+
+// Print writes text on its own line above the status, e.g. a lap
+func (l *StatusLine) Print(text string) {
+	if !l.tty {
+		fmt.Fprintln(l.out, text)
+		return
+	}
+
+	l.clear()
+	fmt.Fprintln(l.out, text)
+	l.lastLen = 0
+	if l.last != "" {
+		l.Update(l.last)
+	}
+}
+
+// Done shows the final text and moves to the next line
+func (l *StatusLine) Done(text string) {
+	if l.tty {
+		fmt.Fprintf(l.out, "\r%s\x1b[K\n", fitWidth(text, l.Width()))
+	} else {
+		fmt.Fprintln(l.out, text)
+	}
+	l.Close()
+}
+
+// Close stops listening to terminal resizes
+func (l *StatusLine) Close() {
+	if l.resize != nil {
+		signal.Stop(l.resize)
+		l.resize = nil
+	}
+}
+
+// visibleLen returns the length of text on screen, without escape sequences
+func visibleLen(text string) int {
+	return len([]rune(stripEscapes(text)))
+}
+
+func stripEscapes(text string) string {
+	var out strings.Builder
+	inEscape := false
+	for _, r := range text {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape:
+			// CSI sequences end with a letter
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEscape = false
+			}
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// fitWidth truncates text to width, colors are dropped when it does not fit
+func fitWidth(text string, width int) string {
+	if visibleLen(text) <= width {
+		return text
+	}
+	return truncate(stripEscapes(text), width)
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFitWidth(t *testing.T) {
+	colored := "\x1b[32m####\x1b[0m done"
+
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"Fits", "Focus 00:10:00", 20, "Focus 00:10:00"},
+		{"Truncated", "Focus 00:10:00", 5, "Focus"},
+		{"Colors kept when it fits", colored, 9, colored},
+		{"Colors dropped when truncated", colored, 6, "#### d"},
+		{"Unicode", "██░░ 50%", 4, "██░░"},
+		{"Zero width", "Focus", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitWidth(tt.text, tt.width); got != tt.want {
+				t.Errorf("fitWidth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElapsedLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		state TimerState
+		want  string
+	}{
+		{"Running", TimerState{Elapsed: 90 * time.Second}, "Time elapsed: 00:01:30"},
+		{"Paused", TimerState{Elapsed: time.Hour, Paused: true}, "Time elapsed: 01:00:00 (paused)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ElapsedLine(tt.state); got != tt.want {
+				t.Errorf("ElapsedLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLayout(t *testing.T) {
+	state := TimerState{Elapsed: 5 * time.Minute, Planned: 10 * time.Minute}
+	paused := TimerState{Elapsed: 5 * time.Minute, Planned: 10 * time.Minute, Paused: true}
+
+	tests := []struct {
+		name   string
+		layout []string
+		state  TimerState
+		width  int
+		want   string
+	}{
+		{"Label before the bar", []string{LayoutLabel, LayoutBar, LayoutPercent}, state, 30,
+			"Focus [#######--------]  50.0%"},
+		{"Items after the bar", []string{LayoutBar, LayoutPercent, LayoutRemaining}, state, 40,
+			"[########--------]  50.0%  00:05:00 left"},
+		{"Bar dropped when narrow", []string{LayoutLabel, LayoutBar, LayoutRemaining}, state, 20,
+			"Focus 00:05:00 left"},
+		{"No ETA while paused", []string{LayoutRemaining, LayoutETA}, paused, 80, "00:05:00 left (paused)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := DefaultTheme()
+			theme.Fill, theme.Empty = "#", "-"
+			theme.Layout = tt.layout
+
+			if got := theme.Render(tt.state, "Focus", tt.width); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
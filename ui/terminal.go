@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// TerminalSize returns the columns and rows of the terminal of f
+func TerminalSize(f *os.File) (int, int, error) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+
+	if ws.Col == 0 || ws.Row == 0 {
+		return 0, 0, fmt.Errorf("unknown terminal size")
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// IsForeground reports whether f is a terminal controlled by this process
+// group. Background jobs are stopped when they read it or change its settings.
+func IsForeground(f *os.File) bool {
+	var pgrp int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp)))
+	if errno != 0 {
+		return false
+	}
+	return int(pgrp) == syscall.Getpgrp()
+}
+
+// MakeRaw puts the terminal of stdin in raw mode, without echo, and returns a
+// function restoring the previous settings.
+func MakeRaw() (func() error, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("saving terminal settings: %w", err)
+	}
+
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("setting raw mode: %w", err)
+	}
+
+	return func() error {
+		_, err := stty(strings.TrimSpace(saved))
+		return err
+	}, nil
+}
+
+// MakeCbreak makes the terminal of stdin send each key as it is pressed,
+// without echo. Unlike raw mode, Ctrl-C still interrupts and the output is
+// not changed. It returns a function restoring the previous settings.
+func MakeCbreak() (func() error, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("saving terminal settings: %w", err)
+	}
+
+	if _, err := stty("-icanon", "-echo", "min", "1", "time", "0"); err != nil {
+		return nil, fmt.Errorf("setting cbreak mode: %w", err)
+	}
+
+	return func() error {
+		_, err := stty(strings.TrimSpace(saved))
+		return err
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return string(output), err
+}
@@ -0,0 +1,303 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout items of the progress line
+const (
+	LayoutLabel     = "label"
+	LayoutBar       = "bar"
+	LayoutPercent   = "percent"
+	LayoutRemaining = "remaining"
+	LayoutETA       = "eta"
+)
+
+var colorCodes = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+}
+
+// colorStop colors the bar from a completion percentage on
+type colorStop struct {
+	percent float64
+	code    string
+}
+
+// Theme defines the glyphs, colors and layout of the progress line, and the
+// glyphs of the other views
+type Theme struct {
+	Fill   string
+	Empty  string
+	Left   string
+	Right  string
+	Layout []string
+	Color  bool
+
+	Rule   string // Line of the dashboard headers
+	Marker string // Focused section of the dashboard
+	Cursor string // End of the text typed in a prompt
+	Block  string // Cell of the big clock digits
+
+	categoryColors map[string]string
+	stops          []colorStop // Sorted by percentage
+}
+
+func DefaultTheme() *Theme {
+	return &Theme{
+		Fill:   "█",
+		Empty:  "░",
+		Left:   "[",
+		Right:  "]",
+		Layout: []string{LayoutBar, LayoutPercent, LayoutRemaining, LayoutETA},
+		Color:  false,
+		Rule:   "─",
+		Marker: "▸",
+		Cursor: "█",
+		Block:  "█",
+	}
+}
+
+// LoadTheme builds the theme from the PROGRESS_* config keys. Colors are
+// enabled only on terminals and never when NO_COLOR is set.
+func LoadTheme(cfg map[string]string) (*Theme, error) {
+	theme := DefaultTheme()
+
+	switch cfg["PROGRESS_THEME"] {
+	case "", "default":
+	case "ascii":
+		theme.Fill, theme.Empty = "#", "-"
+		theme.Rule, theme.Marker, theme.Cursor, theme.Block = "-", ">", "_", "#"
+	default:
+		return nil, fmt.Errorf("unknown progress theme: %s", cfg["PROGRESS_THEME"])
+	}
+
+	for key, glyph := range map[string]*string{
+		"PROGRESS_FILL":  &theme.Fill,
+		"PROGRESS_EMPTY": &theme.Empty,
+		"PROGRESS_LEFT":  &theme.Left,
+		"PROGRESS_RIGHT": &theme.Right,
+	} {
+		if value, ok := cfg[key]; ok {
+			*glyph = value
+		}
+	}
+
+	if len([]rune(theme.Fill)) != 1 || len([]rune(theme.Empty)) != 1 {
+		return nil, fmt.Errorf("PROGRESS_FILL and PROGRESS_EMPTY must be a single character")
+	}
+
+	if layout := cfg["PROGRESS_LAYOUT"]; layout != "" {
+		theme.Layout = nil
+		for item := range strings.SplitSeq(layout, ",") {
+			item = strings.TrimSpace(item)
+			switch item {
+			case LayoutLabel, LayoutBar, LayoutPercent, LayoutRemaining, LayoutETA:
+				theme.Layout = append(theme.Layout, item)
+			default:
+				return nil, fmt.Errorf("unknown progress layout item: %s", item)
+			}
+		}
+	}
+
+	switch cfg["PROGRESS_COLOR"] {
+	case "", "auto":
+		theme.Color = IsTerminal(os.Stdout) && os.Getenv("TERM") != "dumb"
+	case "always":
+		theme.Color = true
+	case "never":
+		theme.Color = false
+	default:
+		return nil, fmt.Errorf("PROGRESS_COLOR must be auto, always or never")
+	}
+
+	// https://no-color.org
+	if os.Getenv("NO_COLOR") != "" {
+		theme.Color = false
+	}
+
+	var err error
+	if theme.categoryColors, err = parseCategoryColors(cfg["CATEGORY_COLORS"]); err != nil {
+		return nil, err
+	}
+
+	if theme.stops, err = parseColorStops(cfg["PROGRESS_COLORS"]); err != nil {
+		return nil, err
+	}
+
+	return theme, nil
+}
+
+// parseCategoryColors parses "Work:blue,Focus:magenta"
+func parseCategoryColors(spec string) (map[string]string, error) {
+	colors := make(map[string]string)
+
+	for item := range strings.SplitSeq(spec, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		category, color, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid category color: %s", item)
+		}
+
+		code, err := colorCode(color)
+		if err != nil {
+			return nil, err
+		}
+		colors[strings.TrimSpace(category)] = code
+	}
+
+	return colors, nil
+}
+
+// parseColorStops parses "0:green,75:yellow,90:red"
+func parseColorStops(spec string) ([]colorStop, error) {
+	var stops []colorStop
+
+	for item := range strings.SplitSeq(spec, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		percentStr, color, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid progress color: %s", item)
+		}
+
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(percentStr), "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid progress color percentage: %s", item)
+		}
+
+		code, err := colorCode(color)
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, colorStop{percent: percent, code: code})
+	}
+
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].percent < stops[j].percent
+	})
+
+	return stops, nil
+}
+
+// colorCode accepts a color name or a 256 color number
+func colorCode(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+
+	if code, ok := colorCodes[color]; ok {
+		return code, nil
+	}
+
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n < 256 {
+		return "38;5;" + color, nil
+	}
+
+	return "", fmt.Errorf("unknown color: %s", color)
+}
+
+// barColor returns the color code of the bar, the category color takes
+// precedence over the completion percentage
+func (t *Theme) barColor(category string, progress float64) string {
+	if !t.Color {
+		return ""
+	}
+
+	if code, ok := t.categoryColors[category]; ok {
+		return code
+	}
+
+	code := ""
+	for _, stop := range t.stops {
+		if progress*100 >= stop.percent {
+			code = stop.code
+		}
+	}
+	return code
+}
+
+func (t *Theme) paint(text, code string) string {
+	if code == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// Bar renders a bar of the given width without its caps
+func (t *Theme) Bar(progress float64, width int) string {
+	filled := int(progress * float64(width))
+	return strings.Repeat(t.Fill, filled) + strings.Repeat(t.Empty, width-filled)
+}
+
+// Render draws the progress line fitting the width. The bar takes the room
+// left by the other items, it is dropped when there is not enough.
+func (t *Theme) Render(state TimerState, label string, width int) string {
+	progress := state.Progress()
+	remaining := state.Remaining()
+
+	var before, after []string
+	hasBar := false
+	for _, item := range t.Layout {
+		var text string
+		switch item {
+		case LayoutLabel:
+			text = label
+		case LayoutPercent:
+			text = fmt.Sprintf("%5.1f%%", progress*100)
+		case LayoutRemaining:
+			text = FormatClock(remaining) + " left"
+			if state.Paused {
+				text += " (paused)"
+			}
+		case LayoutETA:
+			if !state.Paused && remaining > 0 {
+				text = "ETA " + time.Now().Add(remaining).Format("15:04")
+			}
+		case LayoutBar:
+			hasBar = true
+			continue
+		}
+
+		if text == "" {
+			continue
+		}
+		if hasBar {
+			after = append(after, text)
+		} else {
+			before = append(before, text)
+		}
+	}
+
+	prefix := strings.Join(before, " ")
+	suffix := strings.Join(after, "  ")
+	if prefix != "" {
+		prefix += " "
+	}
+	if suffix != "" {
+		suffix = " " + suffix
+	}
+
+	capsLen := len([]rune(t.Left)) + len([]rune(t.Right))
+	barWidth := min(maxBarWidth, width-len([]rune(prefix))-len([]rune(suffix))-capsLen)
+	if !hasBar || barWidth < minBarWidth {
+		return strings.TrimSpace(prefix + strings.TrimSpace(suffix))
+	}
+
+	bar := t.paint(t.Bar(progress, barWidth), t.barColor(label, progress))
+	return prefix + t.Left + bar + t.Right + suffix
+}
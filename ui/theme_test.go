@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestLoadTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		noColor string
+		check   func(t *testing.T, theme *Theme)
+		wantErr bool
+	}{
+		{"Default", nil, "", func(t *testing.T, theme *Theme) {
+			if theme.Fill != "█" || theme.Rule != "─" || theme.Color {
+				t.Errorf("got %+v, want the default glyphs without color", theme)
+			}
+		}, false},
+		{"ASCII", map[string]string{"PROGRESS_THEME": "ascii"}, "", func(t *testing.T, theme *Theme) {
+			for _, glyph := range []string{theme.Fill, theme.Empty, theme.Left, theme.Right, theme.Rule, theme.Marker,
+				theme.Cursor, theme.Block} {
+				for _, r := range glyph {
+					if r > 0x7f {
+						t.Errorf("glyph %q is not ASCII", glyph)
+					}
+				}
+			}
+		}, false},
+		{"Custom glyphs", map[string]string{"PROGRESS_FILL": "=", "PROGRESS_LEFT": "<"}, "", func(t *testing.T, theme *Theme) {
+			if theme.Fill != "=" || theme.Left != "<" || theme.Empty != "░" {
+				t.Errorf("got %+v, want the custom fill and left cap", theme)
+			}
+		}, false},
+		// The output of the tests is not a terminal
+		{"Color auto", map[string]string{"PROGRESS_COLOR": "auto"}, "", func(t *testing.T, theme *Theme) {
+			if theme.Color {
+				t.Error("Color = true, want false outside a terminal")
+			}
+		}, false},
+		{"Color always", map[string]string{"PROGRESS_COLOR": "always"}, "", func(t *testing.T, theme *Theme) {
+			if !theme.Color {
+				t.Error("Color = false, want true")
+			}
+		}, false},
+		{"Color never", map[string]string{"PROGRESS_COLOR": "never"}, "", func(t *testing.T, theme *Theme) {
+			if theme.Color {
+				t.Error("Color = true, want false")
+			}
+		}, false},
+		{"NO_COLOR wins", map[string]string{"PROGRESS_COLOR": "always"}, "1", func(t *testing.T, theme *Theme) {
+			if theme.Color {
+				t.Error("Color = true, want false with NO_COLOR")
+			}
+		}, false},
+		{"Category colors", map[string]string{"CATEGORY_COLORS": "Work:blue, Focus : 208,", "PROGRESS_COLOR": "always"}, "",
+			func(t *testing.T, theme *Theme) {
+				want := map[string]string{"Work": "34", "Focus": "38;5;208"}
+				if !maps.Equal(theme.categoryColors, want) {
+					t.Errorf("categoryColors = %v, want %v", theme.categoryColors, want)
+				}
+				if code := theme.barColor("Focus", 0); code != "38;5;208" {
+					t.Errorf("barColor() = %q, want the category color", code)
+				}
+			}, false},
+		{"Color stops", map[string]string{"PROGRESS_COLORS": "90:red,0:green,75%:yellow", "PROGRESS_COLOR": "always"}, "",
+			func(t *testing.T, theme *Theme) {
+				for progress, want := range map[float64]string{0: "32", 0.8: "33", 0.95: "31"} {
+					if code := theme.barColor("Work", progress); code != want {
+						t.Errorf("barColor(%v) = %q, want %q", progress, code, want)
+					}
+				}
+			}, false},
+		{"Unknown theme", map[string]string{"PROGRESS_THEME": "neon"}, "", nil, true},
+		{"Wide fill", map[string]string{"PROGRESS_FILL": "##"}, "", nil, true},
+		{"Unknown layout", map[string]string{"PROGRESS_LAYOUT": "bar,speed"}, "", nil, true},
+		{"Invalid color mode", map[string]string{"PROGRESS_COLOR": "sometimes"}, "", nil, true},
+		{"Category without color", map[string]string{"CATEGORY_COLORS": "Work"}, "", nil, true},
+		{"Unknown category color", map[string]string{"CATEGORY_COLORS": "Work:teal"}, "", nil, true},
+		{"Color out of range", map[string]string{"CATEGORY_COLORS": "Work:256"}, "", nil, true},
+		{"Invalid stop", map[string]string{"PROGRESS_COLORS": "half:red"}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			theme, err := LoadTheme(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadTheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				tt.check(t, theme)
+			}
+		})
+	}
+}
+
+func TestRenderWidth(t *testing.T) {
+	state := TimerState{Elapsed: 15 * time.Minute, Planned: time.Hour}
+
+	colored := DefaultTheme()
+	colored.Color = true
+	colored.stops = []colorStop{{percent: 0, code: "32"}}
+
+	// The text after the bar, " 25.0%  00:45:00 left  ETA 15:04", takes 33
+	// columns and the caps 2
+	tests := []struct {
+		width int
+		want  int // Visible length
+	}{
+		{0, 31},   // Text only, trimmed
+		{44, 31},  // Too narrow for the minimum bar
+		{45, 45},  // Minimum bar
+		{80, 80},  // Bar filling the width
+		{95, 95},  // Maximum bar
+		{200, 95}, // The bar stops growing
+	}
+
+	for _, theme := range []*Theme{DefaultTheme(), colored} {
+		for _, tt := range tests {
+			got := theme.Render(state, "Focus", tt.width)
+			if length := visibleLen(got); length != tt.want {
+				t.Errorf("Render(width %d) is %d columns, want %d: %q", tt.width, length, tt.want, got)
+			}
+		}
+	}
+}
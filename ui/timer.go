@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer tracks the time of a running task, it can be paused and extended
+// while the progress is being drawn. A zero end means an unlimited task.
+type Timer struct {
+	mu       sync.Mutex
+	init     int64
+	planned  time.Duration
+	paused   time.Duration // Total time spent paused, excluding the current pause
+	pausedAt int64         // Epoch millis of the current pause, zero when running
+	now      func() time.Time
+}
+
+// TimerState is a snapshot of a timer
+type TimerState struct {
+	Elapsed time.Duration // Active time, pauses excluded
+	Planned time.Duration // Zero in unlimited mode
+	Paused  bool
+}
+
+func NewTimer(init, end int64) *Timer {
+	t := &Timer{init: init, now: time.Now}
+	if end != 0 {
+		t.planned = time.Duration(end-init) * time.Millisecond
+	}
+	return t
+}
+
+func (t *Timer) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pausedAt == 0 {
+		t.pausedAt = t.now().UnixMilli()
+	}
+}
+
+func (t *Timer) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pausedAt != 0 {
+		t.paused += t.now().Sub(time.UnixMilli(t.pausedAt))
+		t.pausedAt = 0
+	}
+}
+
+// Extend adds time to a limited timer
+func (t *Timer) Extend(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.planned != 0 {
+		t.planned += d
+	}
+}
+
+func (t *Timer) State() TimerState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now().UnixMilli()
+	paused := t.paused
+	if t.pausedAt != 0 {
+		paused += time.Duration(now-t.pausedAt) * time.Millisecond
+	}
+
+	return TimerState{
+		Elapsed: time.Duration(now-t.init)*time.Millisecond - paused,
+		Planned: t.planned,
+		Paused:  t.pausedAt != 0,
+	}
+}
+
+// End returns the epoch millis when the timer will complete if it is not
+// paused again, zero for unlimited timers.
+func (t *Timer) End() int64 {
+	state := t.State()
+	if state.Planned == 0 {
+		return 0
+	}
+	return t.now().Add(state.Planned - state.Elapsed).UnixMilli()
+}
+
+// Remaining returns the active time left, zero for unlimited timers
+func (s TimerState) Remaining() time.Duration {
+	if s.Planned == 0 || s.Elapsed >= s.Planned {
+		return 0
+	}
+	return s.Planned - s.Elapsed
+}
+
+// Progress returns the completed fraction between 0 and 1
+func (s TimerState) Progress() float64 {
+	if s.Planned == 0 {
+		return 0
+	}
+	return min(float64(s.Elapsed)/float64(s.Planned), 1)
+}
+
+func (s TimerState) Completed() bool {
+	return s.Planned != 0 && s.Elapsed >= s.Planned
+}
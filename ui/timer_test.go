@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is moved forward by the tests instead of waiting
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestTimer(planned time.Duration) (*Timer, *fakeClock) {
+	clock := &fakeClock{now: time.UnixMilli(1_000_000)}
+	init := clock.now.UnixMilli()
+
+	var end int64
+	if planned != 0 {
+		end = init + planned.Milliseconds()
+	}
+
+	timer := NewTimer(init, end)
+	timer.now = clock.Now
+	return timer, clock
+}
+
+func TestTimerPauseResume(t *testing.T) {
+	timer, clock := newTestTimer(10 * time.Minute)
+
+	clock.Advance(2 * time.Minute)
+	timer.Pause()
+	clock.Advance(5 * time.Minute)
+
+	state := timer.State()
+	if !state.Paused || state.Elapsed != 2*time.Minute {
+		t.Errorf("paused State() = %+v, want 2m elapsed and paused", state)
+	}
+
+	// Pausing again keeps the start of the current pause
+	timer.Pause()
+	clock.Advance(time.Minute)
+	timer.Resume()
+	clock.Advance(3 * time.Minute)
+
+	state = timer.State()
+	if state.Paused || state.Elapsed != 5*time.Minute || state.Remaining() != 5*time.Minute {
+		t.Errorf("resumed State() = %+v, want 5m elapsed and 5m remaining", state)
+	}
+
+	// Resuming a running timer changes nothing
+	timer.Resume()
+	if state := timer.State(); state.Elapsed != 5*time.Minute {
+		t.Errorf("State() after a second Resume = %+v, want 5m elapsed", state)
+	}
+
+	if end, want := timer.End(), clock.now.Add(5*time.Minute).UnixMilli(); end != want {
+		t.Errorf("End() = %d, want %d", end, want)
+	}
+
+	clock.Advance(5 * time.Minute)
+	state = timer.State()
+	if !state.Completed() || state.Progress() != 1 || state.Remaining() != 0 {
+		t.Errorf("State() at the deadline = %+v, want completed", state)
+	}
+}
+
+func TestTimerExtendPaused(t *testing.T) {
+	timer, clock := newTestTimer(10 * time.Minute)
+
+	clock.Advance(8 * time.Minute)
+	timer.Pause()
+	timer.Extend(5 * time.Minute)
+	clock.Advance(time.Hour)
+
+	state := timer.State()
+	if state.Planned != 15*time.Minute || state.Elapsed != 8*time.Minute || state.Remaining() != 7*time.Minute {
+		t.Errorf("State() = %+v, want 15m planned, 8m elapsed and 7m remaining", state)
+	}
+
+	timer.Resume()
+	clock.Advance(7 * time.Minute)
+	if state := timer.State(); !state.Completed() {
+		t.Errorf("State() = %+v, want completed after the extension", state)
+	}
+}
+
+func TestTimerUnlimited(t *testing.T) {
+	timer, clock := newTestTimer(0)
+
+	timer.Extend(5 * time.Minute)
+	clock.Advance(24 * time.Hour)
+
+	state := timer.State()
+	if state.Planned != 0 || state.Completed() || state.Remaining() != 0 || state.Progress() != 0 {
+		t.Errorf("State() = %+v, want an unlimited timer never completed", state)
+	}
+	if state.Elapsed != 24*time.Hour {
+		t.Errorf("Elapsed = %s, want 24h", state.Elapsed)
+	}
+	if end := timer.End(); end != 0 {
+		t.Errorf("End() = %d, want 0", end)
+	}
+}
@@ -2,47 +2,64 @@ package ui
 
 import (
 	"fmt"
-	"strings"
+	"os"
 	"time"
 )
 
-func ProgressBar(closeSignal chan bool, init, end int64) {
-	if init >= end {
-		return
+const (
+	minBarWidth = 10
+	maxBarWidth = 60
+)
+
+// ProgressBar draws the progress of the timer with the theme, it returns
+// true when the time is completed and false when it was interrupted by
+// closeSignal. The prompt of the keyboard replaces the bar while it is open,
+// keyboard may be nil.
+func ProgressBar(closeSignal chan bool, timer *Timer, theme *Theme, label string, keyboard *Keyboard) bool {
+	if timer.State().Completed() {
+		return true
 	}
 
-	const width = 50
-	bar := fmt.Sprintf("[%s]", strings.Repeat(" ", width))
-	duration := time.Duration(end-init) * time.Millisecond
+	if theme == nil {
+		theme = DefaultTheme()
+	}
+
+	line := NewStatusLine(os.Stdout)
 	ticker := time.NewTicker(time.Millisecond * 500)
 	defer ticker.Stop()
 
-	fmt.Println()
-	start := time.Now()
+	if line.IsTerminal() {
+		fmt.Println()
+	}
 
 	for {
 
 		select {
 		case <-closeSignal:
 			// Clean up the progress bar and exit
-			fmt.Printf("\r%s 100.0%%\n\n",
-				bar[:1]+strings.Repeat("█", width)+bar[width+1:])
-			return
+			line.Done(theme.Render(timer.State(), label, line.Width()))
+			fmt.Println()
+			return false
 		case <-ticker.C:
-			elapsed := time.Since(start)
-			progress := float64(elapsed) / float64(duration)
+			state := timer.State()
 
-			if progress >= 1.0 {
-				fmt.Printf("\r%s 100.0%%\n",
-					bar[:1]+strings.Repeat("█", width)+bar[width+1:])
-				return
+			if state.Completed() {
+				line.Done(theme.Render(state, label, line.Width()))
+				return true
 			}
 
-			filled := int(progress * float64(width))
-			progressBar := bar[:1] + strings.Repeat("█", filled) +
-				strings.Repeat("░", width-filled) + bar[width+1:]
-
-			fmt.Printf("\r%s %.1f%%", progressBar, progress*100)
+			line.Update(keyboard.Status(theme.Render(state, label, line.Width())))
+		case <-keyboard.Changed():
+			line.Update(keyboard.Status(theme.Render(timer.State(), label, line.Width())))
 		}
 	}
 }
+
+// ElapsedLine renders the time of an unlimited task
+func ElapsedLine(state TimerState) string {
+	text := "Time elapsed: " + FormatClock(state.Elapsed)
+	if state.Paused {
+		text += " (paused)"
+	}
+	return text
+}